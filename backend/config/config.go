@@ -4,6 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/blockvantage/chain-app-store/backend/utils"
 )
 
 // Config represents the structure of the config.json file
@@ -19,6 +23,15 @@ type Config struct {
 	ListingFee      ListingFeeConfig  `json:"listingFee"`
 	BackendUrl      string            `json:"backendUrl"`
 	Storage         StorageConfig     `json:"storage"`
+	Poe             PoeConfig         `json:"poe"`
+	Moderation      ModerationConfig  `json:"moderation"`
+	Multisig        MultisigConfig    `json:"multisig"`
+	LinkHealth      LinkHealthConfig  `json:"linkHealth"`
+
+	// SigningKey is the backend's private key used to sign receipts. It is
+	// read from the SIGNING_KEY environment variable and never serialized.
+	SigningKey      string            `json:"-"`
+	SignerAddress   string            `json:"-"`
 }
 
 type LogoConfig struct {
@@ -51,6 +64,7 @@ type PublicConfig struct {
 	Logos           LogoConfig        `json:"logos"`
 	EnableModules   ModulesConfig     `json:"enableModules"`
 	ListingFee      ListingFeeConfig  `json:"listingFee"`
+	SignerAddress   string            `json:"signerAddress,omitempty"`
 }
 
 // LoadConfig loads the configuration from the specified path
@@ -65,6 +79,15 @@ func LoadConfig(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config JSON: %w", err)
 	}
 
+	if key := os.Getenv("SIGNING_KEY"); key != "" {
+		address, err := utils.GetAddressFromPrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SIGNING_KEY: %w", err)
+		}
+		config.SigningKey = key
+		config.SignerAddress = address
+	}
+
 	return &config, nil
 }
 
@@ -78,7 +101,24 @@ func (c *Config) GetPublicConfig() PublicConfig {
 		Logos:         c.Logos,
 		EnableModules: c.EnableModules,
 		ListingFee:    c.ListingFee,
+		SignerAddress: c.SignerAddress,
+	}
+}
+
+// ExplorerTxURL returns the block explorer link for a transaction hash
+func (c *Config) ExplorerTxURL(hash string) string {
+	if c.ExplorerUrl == "" || hash == "" {
+		return ""
+	}
+	return strings.TrimSuffix(c.ExplorerUrl, "/") + "/tx/" + hash
+}
+
+// ExplorerAddressURL returns the block explorer link for an address
+func (c *Config) ExplorerAddressURL(address string) string {
+	if c.ExplorerUrl == "" || address == "" {
+		return ""
 	}
+	return strings.TrimSuffix(c.ExplorerUrl, "/") + "/address/" + address
 }
 
 // IsAdminWallet checks if the given wallet address is an admin
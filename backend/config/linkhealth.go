@@ -0,0 +1,55 @@
+package config
+
+import "time"
+
+// LinkHealthConfig holds configuration for the background checker that
+// probes the links of listed apps
+type LinkHealthConfig struct {
+	// IntervalMinutes is the time between check runs. Zero uses the default
+	// of 360 and a negative value disables the checker.
+	IntervalMinutes int `json:"intervalMinutes"`
+	// FailureThreshold is the number of consecutive failed checks after
+	// which a link counts as broken (default 3)
+	FailureThreshold int `json:"failureThreshold"`
+	// TimeoutSeconds bounds each probe (default 10)
+	TimeoutSeconds int `json:"timeoutSeconds"`
+	// HistoryDays is how long individual check results are kept (default 30)
+	HistoryDays int `json:"historyDays"`
+}
+
+// Interval returns the time between check runs, or zero when the checker
+// is disabled
+func (l LinkHealthConfig) Interval() time.Duration {
+	switch {
+	case l.IntervalMinutes < 0:
+		return 0
+	case l.IntervalMinutes == 0:
+		return 6 * time.Hour
+	}
+	return time.Duration(l.IntervalMinutes) * time.Minute
+}
+
+// BrokenAfter returns the number of consecutive failures that marks a link
+// as broken
+func (l LinkHealthConfig) BrokenAfter() int {
+	if l.FailureThreshold <= 0 {
+		return 3
+	}
+	return l.FailureThreshold
+}
+
+// Timeout returns the time limit of a single probe
+func (l LinkHealthConfig) Timeout() time.Duration {
+	if l.TimeoutSeconds <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(l.TimeoutSeconds) * time.Second
+}
+
+// Retention returns how long check results are kept
+func (l LinkHealthConfig) Retention() time.Duration {
+	if l.HistoryDays <= 0 {
+		return 30 * 24 * time.Hour
+	}
+	return time.Duration(l.HistoryDays) * 24 * time.Hour
+}
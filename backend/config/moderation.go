@@ -0,0 +1,41 @@
+package config
+
+// ModerationConfig holds configuration for content moderation
+type ModerationConfig struct {
+	// FlagThreshold is the number of independent open reports after which
+	// flagged content is hidden automatically. Zero uses the default of 5
+	// and a negative value disables auto-hiding.
+	FlagThreshold int `json:"flagThreshold"`
+	// PhishingBlocklist is the path of a file of known phishing domains,
+	// one per line. Submitted links on a listed domain are rejected. Empty
+	// disables the check.
+	PhishingBlocklist string `json:"phishingBlocklist"`
+	// DirectHidesPerHour caps how many apps, reviews and replies one admin
+	// can hide, unhide or resolve flags on per hour without a proposal.
+	// Zero uses the default of 20 and a negative value removes the cap.
+	DirectHidesPerHour int `json:"directHidesPerHour"`
+}
+
+// AutoHideThreshold returns the report count that hides content, or zero
+// when auto-hiding is disabled
+func (m ModerationConfig) AutoHideThreshold() int {
+	switch {
+	case m.FlagThreshold < 0:
+		return 0
+	case m.FlagThreshold == 0:
+		return 5
+	}
+	return m.FlagThreshold
+}
+
+// DirectHideLimit returns how many direct hides an admin can make per hour,
+// or zero when they are not capped
+func (m ModerationConfig) DirectHideLimit() int {
+	switch {
+	case m.DirectHidesPerHour < 0:
+		return 0
+	case m.DirectHidesPerHour == 0:
+		return 20
+	}
+	return m.DirectHidesPerHour
+}
@@ -0,0 +1,29 @@
+package config
+
+import "time"
+
+// MultisigConfig holds configuration for admin actions that need approval
+// from several admins
+type MultisigConfig struct {
+	// Threshold is the number of admin signatures, including the proposer's,
+	// that executes a proposal (default 2)
+	Threshold int `json:"threshold"`
+	// ExpiryHours is how long a proposal can collect signatures (default 72)
+	ExpiryHours int `json:"expiryHours"`
+}
+
+// RequiredApprovals returns the number of signatures that executes a proposal
+func (m MultisigConfig) RequiredApprovals() int {
+	if m.Threshold <= 0 {
+		return 2
+	}
+	return m.Threshold
+}
+
+// Expiry returns how long a proposal stays open
+func (m MultisigConfig) Expiry() time.Duration {
+	if m.ExpiryHours <= 0 {
+		return 72 * time.Hour
+	}
+	return time.Duration(m.ExpiryHours) * time.Hour
+}
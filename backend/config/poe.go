@@ -0,0 +1,91 @@
+package config
+
+import "time"
+
+// PoeConfig holds configuration for the POE (Proof of Engagement) plugin
+type PoeConfig struct {
+	// Rules maps each allowed action to its point rule. Actions that are not
+	// listed are rejected.
+	Rules map[string]PoeRule `json:"rules"`
+
+	// SignatureWindowSeconds is how far an engagement timestamp may drift
+	// from the server clock before the signature is rejected
+	SignatureWindowSeconds int `json:"signatureWindowSeconds"`
+	// MaxRequestsPerMinute limits engagement requests per address
+	MaxRequestsPerMinute int `json:"maxRequestsPerMinute"`
+	// WalletCriteria are optional minimums a wallet must meet to earn points
+	WalletCriteria WalletCriteria `json:"walletCriteria"`
+}
+
+// WalletCriteria are checked against the chain via RPC. Zero values disable
+// the corresponding check.
+type WalletCriteria struct {
+	// MinNonce is the minimum number of transactions sent by the wallet
+	MinNonce uint64 `json:"minNonce"`
+	// MinBalanceWei is the minimum native balance, as a decimal wei string
+	MinBalanceWei string `json:"minBalanceWei"`
+}
+
+// Enabled reports whether any wallet criteria are configured
+func (w WalletCriteria) Enabled() bool {
+	return w.MinNonce > 0 || (w.MinBalanceWei != "" && w.MinBalanceWei != "0")
+}
+
+// PoeRule defines how an engagement action is rewarded
+type PoeRule struct {
+	// Points awarded for a single engagement
+	Points int `json:"points"`
+	// CooldownSeconds is the minimum time between two rewarded engagements
+	// of this action by the same user on the same app
+	CooldownSeconds int `json:"cooldownSeconds"`
+	// DailyCap is the maximum number of points a user can earn from this
+	// action per UTC day across all apps. Zero means no cap.
+	DailyCap int `json:"dailyCap"`
+	// TxMultiplier multiplies the points when the engagement is backed by
+	// a transaction verified on-chain. Zero or one means no bonus.
+	TxMultiplier float64 `json:"txMultiplier"`
+	// RequireTx makes the action only count when backed by a transaction
+	// sent by the user to one of the app's contracts
+	RequireTx bool `json:"requireTx"`
+}
+
+// DefaultPoeRules are used when the config does not define any rules
+var DefaultPoeRules = map[string]PoeRule{
+	"visit": {Points: 1, CooldownSeconds: 3600, DailyCap: 10},
+	"use":   {Points: 5, CooldownSeconds: 600, DailyCap: 100, RequireTx: true},
+	"share": {Points: 3, CooldownSeconds: 86400, DailyCap: 15},
+}
+
+// SignatureWindow returns the allowed timestamp drift, defaulting to 5 minutes
+func (p PoeConfig) SignatureWindow() time.Duration {
+	if p.SignatureWindowSeconds <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(p.SignatureWindowSeconds) * time.Second
+}
+
+// RequestsPerMinute returns the per-address rate limit, defaulting to 20
+func (p PoeConfig) RequestsPerMinute() int {
+	if p.MaxRequestsPerMinute <= 0 {
+		return 20
+	}
+	return p.MaxRequestsPerMinute
+}
+
+// Rule returns the rule for an action and whether the action is allowed
+func (p PoeConfig) Rule(action string) (PoeRule, bool) {
+	rules := p.Rules
+	if len(rules) == 0 {
+		rules = DefaultPoeRules
+	}
+	rule, ok := rules[action]
+	return rule, ok
+}
+
+// AllRules returns the effective rule set
+func (p PoeConfig) AllRules() map[string]PoeRule {
+	if len(p.Rules) == 0 {
+		return DefaultPoeRules
+	}
+	return p.Rules
+}
@@ -3,4 +3,25 @@ package config
 // StorageConfig holds configuration for file storage
 type StorageConfig struct {
 	ImagesPath string `json:"imagesPath"`
+	// MaxImageBytes is the largest accepted upload (default 5 MiB)
+	MaxImageBytes int64 `json:"maxImageBytes"`
+	// MaxImagePixels bounds width times height of an uploaded image, so
+	// small files cannot decode to huge bitmaps (default 16 megapixels)
+	MaxImagePixels int `json:"maxImagePixels"`
+}
+
+// ImageByteLimit returns the largest accepted image upload in bytes
+func (s StorageConfig) ImageByteLimit() int64 {
+	if s.MaxImageBytes <= 0 {
+		return 5 << 20
+	}
+	return s.MaxImageBytes
+}
+
+// ImagePixelLimit returns the largest accepted image area in pixels
+func (s StorageConfig) ImagePixelLimit() int {
+	if s.MaxImagePixels <= 0 {
+		return 16 << 20
+	}
+	return s.MaxImagePixels
 }
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -52,6 +54,14 @@ func main() {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
+	// Maintenance commands run against the database and exit
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1], db, cfg); err != nil {
+			log.Fatalf("%s failed: %v", os.Args[1], err)
+		}
+		return
+	}
+
 	// Initialize router
 	router := gin.Default()
 
@@ -70,12 +80,18 @@ func main() {
 		c.Next()
 	})
 
+	// Reject writes by banned wallets on every route
+	router.Use(storage.BanMiddleware(db))
+
 	// Register core routes
 	registerCoreRoutes(router, db, cfg)
 
 	// Register plugin routes based on config
 	registerPluginRoutes(router, db, cfg)
 
+	// Probe the links of listed apps in the background
+	go storage.NewLinkChecker(db, cfg).Run(context.Background())
+
 	// Start server
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -88,6 +104,66 @@ func main() {
 	}
 }
 
+// runCommand runs a maintenance command given on the command line
+func runCommand(name string, db *storage.DB, cfg *config.Config) error {
+	switch name {
+	case "rebuild-aggregates":
+		log.Println("Rebuilding aggregate tables...")
+		if err := storage.RebuildAggregates(db, cfg); err != nil {
+			return err
+		}
+		return verifyAggregates(db, cfg)
+	case "verify-aggregates":
+		return verifyAggregates(db, cfg)
+	case "index-contracts":
+		if err := storage.IndexAppContracts(db.DB); err != nil {
+			return err
+		}
+		log.Println("App contracts indexed")
+		return nil
+	case "hash-logos":
+		hashed, err := storage.HashAppLogos(db, cfg)
+		if err != nil {
+			return err
+		}
+		log.Printf("Hashed %d app logos", hashed)
+		return nil
+	case "image-variants":
+		processed, err := storage.CreateImageVariants(db, cfg)
+		if err != nil {
+			return err
+		}
+		log.Printf("Created size variants of %d images", processed)
+		return nil
+	case "check-links":
+		summary, err := storage.NewLinkChecker(db, cfg).CheckAll(context.Background())
+		if err != nil {
+			return err
+		}
+		log.Printf("Checked %d links of %d apps: %d failed, %d apps broken",
+			summary.Links, summary.Apps, summary.Failed, summary.BrokenApps)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q (available: rebuild-aggregates, verify-aggregates, index-contracts, hash-logos, image-variants, check-links)", name)
+	}
+}
+
+// verifyAggregates checks the aggregate tables against their source rows
+func verifyAggregates(db *storage.DB, cfg *config.Config) error {
+	mismatches, err := storage.VerifyAggregates(db, cfg)
+	if err != nil {
+		return err
+	}
+	for _, m := range mismatches {
+		log.Println(m)
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%d aggregate rows are inconsistent", len(mismatches))
+	}
+	log.Println("Aggregate tables are consistent")
+	return nil
+}
+
 func registerCoreRoutes(router *gin.Engine, db *storage.DB, cfg *config.Config) {
 	// Get the base path from environment variable, default to empty string
 	basePath := os.Getenv("API_BASE_PATH")
@@ -108,15 +184,40 @@ func registerCoreRoutes(router *gin.Engine, db *storage.DB, cfg *config.Config)
 		// App routes
 		api.GET("/apps", storage.GetApps(db))
 		api.GET("/apps/:id", storage.GetApp(db))
+		api.GET("/apps/:id/verification", storage.GetAppVerification(db))
+		api.POST("/apps/:id/verify", storage.VerifyDeveloper(db, cfg))
+		api.GET("/contracts/:address", storage.GetContractApp(db, cfg))
 		api.POST("/apps", storage.CreateApp(db, cfg))
+		api.POST("/flags", storage.CreateFlag(db, cfg))
 		api.Static("/images", cfg.Storage.ImagesPath)
 
 		// Admin routes with authentication middleware
 		admin := api.Group("/admin")
-		admin.Use(storage.AdminAuthMiddleware(cfg))
 		{
-			admin.POST("/feature", storage.FeatureApp(db))
-			admin.POST("/hide", storage.HideApp(db))
+			auth := func(permission string) gin.HandlerFunc {
+				return storage.AdminAuthMiddleware(db, permission)
+			}
+			admin.POST("/feature", auth(storage.PermFeatureApps), storage.FeatureApp(db))
+			admin.POST("/hide", auth(storage.PermHideContent), storage.LimitDirectHides(db, cfg), storage.HideApp(db))
+			admin.POST("/apps/:id/unverify", auth(storage.PermFeatureApps), storage.RevokeVerification(db))
+			admin.GET("/flags", auth(storage.PermHideContent), storage.GetFlags(db))
+			admin.POST("/flags/:id/resolve", auth(storage.PermHideContent), storage.LimitDirectHides(db, cfg), storage.ResolveFlag(db, false))
+			admin.POST("/flags/:id/dismiss", auth(storage.PermHideContent), storage.ResolveFlag(db, true))
+			admin.GET("/audit", auth(storage.PermViewAudit), storage.GetAuditLog(db))
+			admin.GET("/roles", auth(storage.PermManageAdmins), storage.GetAdminRoles(db))
+			admin.POST("/roles", auth(storage.PermManageAdmins), storage.SetAdminRole(db))
+			admin.GET("/bans", auth(storage.PermHideContent), storage.GetBans(db))
+			admin.POST("/bans", auth(storage.PermHideContent), storage.CreateBan(db))
+			admin.POST("/bans/:id/lift", auth(storage.PermHideContent), storage.LiftBan(db))
+			admin.GET("/blocklist", auth(storage.PermHideContent), storage.GetPhishingBlocklist(cfg))
+			admin.POST("/blocklist", auth(storage.PermHideContent), storage.UpdatePhishingBlocklist(db, cfg))
+			admin.GET("/link-health", auth(storage.PermHideContent), storage.GetLinkHealthReport(db))
+			admin.GET("/link-health/:id", auth(storage.PermHideContent), storage.GetLinkHistory(db))
+			admin.GET("/proposals", auth(""), storage.GetProposals(db))
+			admin.GET("/proposals/:id", auth(""), storage.GetProposal(db))
+			admin.POST("/proposals", auth(""), storage.CreateProposal(db, cfg))
+			admin.POST("/proposals/:id/approve", auth(""), storage.ApproveProposal(db))
+			admin.POST("/proposals/:id/cancel", auth(""), storage.CancelProposal(db))
 		}
 	}
 }
@@ -3,8 +3,10 @@ package boosting
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
@@ -21,6 +23,9 @@ func RegisterRoutes(router *gin.RouterGroup, db *storage.DB, cfg *config.Config)
 	// Register routes
 	router.POST("/boost", createBoost(db, cfg))
 	router.GET("/boosted", getBoostedApps(db))
+	router.GET("/apps/:id/boosts", getAppBoosts(db, cfg))
+	router.GET("/users/:address/boosts", getUserBoosts(db, cfg))
+	router.GET("/boosts/:boostId/receipt", getBoostReceipt(db, cfg))
 	
 	return nil
 }
@@ -147,3 +152,133 @@ func getBoostedApps(db *storage.DB) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"apps": boostedApps})
 	}
 }
+
+// BoostEntry is a boost as returned by the history endpoints
+type BoostEntry struct {
+	storage.Boost
+	Active      bool   `json:"active"`
+	ExplorerURL string `json:"explorerUrl,omitempty"`
+	ReceiptURL  string `json:"receiptUrl"`
+}
+
+func newBoostEntry(boost storage.Boost, cfg *config.Config, now time.Time) BoostEntry {
+	return BoostEntry{
+		Boost:       boost,
+		Active:      boost.ExpiresAt.After(now),
+		ExplorerURL: cfg.ExplorerTxURL(boost.TxHash),
+		ReceiptURL:  "/boosts/" + strconv.FormatUint(uint64(boost.ID), 10) + "/receipt",
+	}
+}
+
+// listBoosts runs a paginated boost query and writes the history response
+func listBoosts(c *gin.Context, query *gorm.DB, cfg *config.Config, extra gin.H) {
+	// Optionally restrict to active or expired boosts
+	now := time.Now()
+	switch c.Query("status") {
+	case "active":
+		query = query.Where("expires_at > ?", now)
+	case "expired":
+		query = query.Where("expires_at <= ?", now)
+	case "":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be active or expired"})
+		return
+	}
+
+	page, pageSize, offset := utils.ParsePagination(c)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var boosts []storage.Boost
+	if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&boosts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries := make([]BoostEntry, 0, len(boosts))
+	for _, boost := range boosts {
+		entries = append(entries, newBoostEntry(boost, cfg, now))
+	}
+
+	response := gin.H{
+		"boosts":     entries,
+		"pagination": utils.PaginationMeta(total, page, pageSize),
+	}
+	for k, v := range extra {
+		response[k] = v
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// getAppBoosts returns the boost history for an app
+func getAppBoosts(db *storage.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid app ID"})
+			return
+		}
+
+		// Check if the app exists
+		var app storage.App
+		if err := db.First(&app, appID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "app not found"})
+			return
+		}
+
+		query := db.Model(&storage.Boost{}).Where("app_id = ?", appID)
+		listBoosts(c, query, cfg, gin.H{"appId": appID, "appName": app.Name})
+	}
+}
+
+// getUserBoosts returns the boost history for a booster address
+func getUserBoosts(db *storage.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address := c.Param("address")
+		if !common.IsHexAddress(address) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address"})
+			return
+		}
+
+		query := db.Model(&storage.Boost{}).Where("LOWER(user_address) = ?", strings.ToLower(address))
+		listBoosts(c, query, cfg, gin.H{
+			"userAddress": address,
+			"explorerUrl": cfg.ExplorerAddressURL(address),
+		})
+	}
+}
+
+// getBoostReceipt returns the signed receipt for a boost
+func getBoostReceipt(db *storage.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cfg.SigningKey == "" {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "receipt signing is not configured"})
+			return
+		}
+
+		boostID, err := strconv.ParseUint(c.Param("boostId"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid boost ID"})
+			return
+		}
+
+		var boost storage.Boost
+		if err := db.First(&boost, boostID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "boost not found"})
+			return
+		}
+
+		receipt, err := NewReceipt(boost, cfg)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to sign receipt"})
+			return
+		}
+
+		c.JSON(http.StatusOK, receipt)
+	}
+}
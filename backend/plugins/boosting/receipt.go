@@ -0,0 +1,73 @@
+package boosting
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/blockvantage/chain-app-store/backend/config"
+	"github.com/blockvantage/chain-app-store/backend/storage"
+	"github.com/blockvantage/chain-app-store/backend/utils"
+)
+
+// Receipt is a signed record of a boost. The Message field is signed with
+// the backend's key using personal_sign, so a booster can verify it offline
+// by recovering the signer from Message and Signature and comparing it to
+// the signerAddress published in /config.
+type Receipt struct {
+	BoostID     uint      `json:"boostId"`
+	AppID       uint      `json:"appId"`
+	UserAddress string    `json:"userAddress"`
+	Amount      string    `json:"amount"`
+	TokenSymbol string    `json:"tokenSymbol"`
+	TxHash      string    `json:"txHash"`
+	ChainName   string    `json:"chainName"`
+	BoostedAt   time.Time `json:"boostedAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	ExplorerURL string    `json:"explorerUrl,omitempty"`
+	Message     string    `json:"message"`
+	Signature   string    `json:"signature"`
+	Signer      string    `json:"signer"`
+}
+
+// receiptMessage builds the canonical text that is signed for a boost
+func receiptMessage(boost storage.Boost, chainName string) string {
+	lines := []string{
+		"Chain App Hub boost receipt",
+		"Chain: " + chainName,
+		fmt.Sprintf("Boost ID: %d", boost.ID),
+		fmt.Sprintf("App ID: %d", boost.AppID),
+		"Booster: " + boost.UserAddress,
+		"Amount: " + boost.Amount + " " + boost.TokenSymbol,
+		"Tx: " + boost.TxHash,
+		"Boosted At: " + boost.CreatedAt.UTC().Format(time.RFC3339),
+		"Expires At: " + boost.ExpiresAt.UTC().Format(time.RFC3339),
+	}
+	return strings.Join(lines, "\n")
+}
+
+// NewReceipt builds and signs the receipt for a boost
+func NewReceipt(boost storage.Boost, cfg *config.Config) (*Receipt, error) {
+	message := receiptMessage(boost, cfg.ChainName)
+
+	signature, err := utils.SignMessage(cfg.SigningKey, message)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Receipt{
+		BoostID:     boost.ID,
+		AppID:       boost.AppID,
+		UserAddress: boost.UserAddress,
+		Amount:      boost.Amount,
+		TokenSymbol: boost.TokenSymbol,
+		TxHash:      boost.TxHash,
+		ChainName:   cfg.ChainName,
+		BoostedAt:   boost.CreatedAt.UTC(),
+		ExpiresAt:   boost.ExpiresAt.UTC(),
+		ExplorerURL: cfg.ExplorerTxURL(boost.TxHash),
+		Message:     message,
+		Signature:   signature,
+		Signer:      cfg.SignerAddress,
+	}, nil
+}
@@ -0,0 +1,152 @@
+package poe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blockvantage/chain-app-store/backend/config"
+	"github.com/blockvantage/chain-app-store/backend/utils"
+)
+
+var (
+	// ErrStaleSignature is returned when the signed timestamp is outside the window
+	ErrStaleSignature = errors.New("signature timestamp is outside the allowed window")
+	// ErrRateLimited is returned when an address sends too many engagements
+	ErrRateLimited = errors.New("too many engagement requests")
+	// ErrWalletCriteria is returned when a wallet does not meet the minimums
+	ErrWalletCriteria = errors.New("wallet does not meet the minimum criteria")
+)
+
+// walletCacheTTL is how long a passing wallet criteria check is remembered
+const walletCacheTTL = 15 * time.Minute
+
+// Guard holds the anti-sybil state shared by engagement requests
+type Guard struct {
+	cfg *config.Config
+
+	mu       sync.Mutex
+	requests map[string][]time.Time
+	wallets  map[string]time.Time // address -> time the criteria check passed
+}
+
+// NewGuard creates a guard for the configured limits
+func NewGuard(cfg *config.Config) *Guard {
+	return &Guard{
+		cfg:      cfg,
+		requests: make(map[string][]time.Time),
+		wallets:  make(map[string]time.Time),
+	}
+}
+
+// engagementMessage builds the text a user signs to log an engagement
+func engagementMessage(appID uint, action, nonce string, timestamp int64, txHash string) string {
+	message := fmt.Sprintf("Engage with app %d with action %s\nNonce: %s\nTimestamp: %d", appID, action, nonce, timestamp)
+	if txHash != "" {
+		message += "\nTx: " + txHash
+	}
+	return message
+}
+
+// CheckTimestamp rejects signatures whose timestamp is too far from now
+func (g *Guard) CheckTimestamp(timestamp int64, now time.Time) error {
+	drift := now.Sub(time.Unix(timestamp, 0))
+	if drift < 0 {
+		drift = -drift
+	}
+	if drift > g.cfg.Poe.SignatureWindow() {
+		return ErrStaleSignature
+	}
+	return nil
+}
+
+// Allow records a request from address and reports whether it is within the
+// per-minute limit
+func (g *Guard) Allow(address string, now time.Time) error {
+	key := strings.ToLower(address)
+	windowStart := now.Add(-time.Minute)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	recent := g.requests[key][:0]
+	for _, t := range g.requests[key] {
+		if t.After(windowStart) {
+			recent = append(recent, t)
+		}
+	}
+
+	if len(recent) >= g.cfg.Poe.RequestsPerMinute() {
+		g.requests[key] = recent
+		return ErrRateLimited
+	}
+
+	g.requests[key] = append(recent, now)
+
+	// Drop idle addresses so the map does not grow without bound
+	if len(g.requests) > 10000 {
+		for addr, times := range g.requests {
+			if len(times) == 0 || !times[len(times)-1].After(windowStart) {
+				delete(g.requests, addr)
+			}
+		}
+	}
+
+	return nil
+}
+
+// CheckWallet verifies the optional minimum wallet criteria via RPC
+func (g *Guard) CheckWallet(ctx context.Context, address string) error {
+	criteria := g.cfg.Poe.WalletCriteria
+	if !criteria.Enabled() {
+		return nil
+	}
+
+	key := strings.ToLower(address)
+	g.mu.Lock()
+	passedAt, ok := g.wallets[key]
+	g.mu.Unlock()
+	if ok && time.Since(passedAt) < walletCacheTTL {
+		return nil
+	}
+
+	client, err := utils.DialChain(ctx, g.cfg.RpcUrl)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if criteria.MinNonce > 0 {
+		nonce, err := client.NonceAt(ctx, address)
+		if err != nil {
+			return err
+		}
+		if nonce < criteria.MinNonce {
+			return fmt.Errorf("%w: at least %d transactions required", ErrWalletCriteria, criteria.MinNonce)
+		}
+	}
+
+	if criteria.MinBalanceWei != "" {
+		minBalance, ok := new(big.Int).SetString(criteria.MinBalanceWei, 10)
+		if !ok {
+			return fmt.Errorf("invalid minBalanceWei %q", criteria.MinBalanceWei)
+		}
+		balance, err := client.BalanceAt(ctx, address)
+		if err != nil {
+			return err
+		}
+		if balance.Cmp(minBalance) < 0 {
+			return fmt.Errorf("%w: balance of at least %s wei required", ErrWalletCriteria, criteria.MinBalanceWei)
+		}
+	}
+
+	g.mu.Lock()
+	g.wallets[key] = time.Now()
+	g.mu.Unlock()
+
+	return nil
+}
@@ -1,9 +1,14 @@
 package poe
 
 import (
+	"errors"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
@@ -18,25 +23,43 @@ type Plugin struct{}
 // RegisterRoutes registers the POE plugin routes
 func RegisterRoutes(router *gin.RouterGroup, db *storage.DB, cfg *config.Config) error {
 	// Register routes
-	router.POST("/engage", logEngagement(db))
+	router.POST("/engage", logEngagement(db, cfg, NewGuard(cfg)))
+	router.GET("/poe/rules", getRules(cfg))
 	router.GET("/leaderboard", getLeaderboard(db))
+	router.GET("/leaderboard/:address", getUserPosition(db))
 	router.GET("/contributions/:appId", getAppContributions(db))
+	router.GET("/poe/seasons", getSeasons(db))
+	router.GET("/poe/distributions", getDistributions(db))
+	router.GET("/poe/distributions/:id", getDistribution(db))
+	router.GET("/poe/distributions/:id/export", exportDistribution(db))
+	router.GET("/poe/distributions/:id/proofs/:address", getDistributionProof(db))
+
+	// Admin routes for season management
+	admin := router.Group("/admin")
+	admin.Use(storage.AdminAuthMiddleware(db, storage.PermManageSettlements))
+	{
+		admin.POST("/poe/seasons", openSeason(db))
+		admin.POST("/poe/seasons/:id/close", closeSeason(db))
+		admin.POST("/poe/distributions", createDistribution(db))
+	}
 	
 	return nil
 }
 
 // Migrate runs the migrations for the POE plugin
 func Migrate(db *gorm.DB) error {
-	return db.AutoMigrate(&storage.Point{})
+	return db.AutoMigrate(&storage.Point{}, &storage.UserPointTotal{}, &storage.AppUserPointTotal{}, &storage.EngagementNonce{}, &storage.Season{}, &storage.SeasonStanding{}, &storage.RewardDistribution{}, &storage.RewardAllocation{})
 }
 
 // logEngagement handles logging a user engagement with an app
-func logEngagement(db *storage.DB) gin.HandlerFunc {
+func logEngagement(db *storage.DB, cfg *config.Config, guard *Guard) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req struct {
 			AppID       uint   `json:"appId" binding:"required"`
 			UserAddress string `json:"userAddress" binding:"required"`
 			Action      string `json:"action" binding:"required"`
+			Nonce       string `json:"nonce" binding:"required,max=64"`
+			Timestamp   int64  `json:"timestamp" binding:"required"`
 			Signature   string `json:"signature" binding:"required"`
 			TxHash      string `json:"txHash"`
 		}
@@ -46,14 +69,27 @@ func logEngagement(db *storage.DB) gin.HandlerFunc {
 			return
 		}
 
+		now := time.Now()
+		if err := guard.CheckTimestamp(req.Timestamp, now); err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
 		// Verify the signature
-		message := "Engage with app " + strconv.Itoa(int(req.AppID)) + " with action " + req.Action
+		message := engagementMessage(req.AppID, req.Action, req.Nonce, req.Timestamp, req.TxHash)
 		valid, err := utils.VerifySignature(req.UserAddress, req.Signature, message)
 		if err != nil || !valid {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
 			return
 		}
 
+		// Rate limit only after the signature proves who is asking
+		if err := guard.Allow(req.UserAddress, now); err != nil {
+			c.Header("Retry-After", "60")
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+
 		// Check if the app exists
 		var app storage.App
 		if err := db.First(&app, req.AppID).Error; err != nil {
@@ -61,29 +97,116 @@ func logEngagement(db *storage.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Determine points based on action
-		var points int
-		switch req.Action {
-		case "visit":
-			points = 1
-		case "use":
-			points = 5
-		case "share":
-			points = 3
-		default:
-			points = 1
+		// Reject replayed nonces
+		var used int64
+		if err := db.Model(&storage.EngagementNonce{}).
+			Where("user_address = ? AND nonce = ?", strings.ToLower(req.UserAddress), req.Nonce).
+			Count(&used).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if used > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "nonce already used"})
+			return
+		}
+
+		// A transaction can only back a single engagement
+		if req.TxHash != "" {
+			var existing int64
+			if err := db.Model(&storage.Point{}).Where("LOWER(tx_hash) = ?", strings.ToLower(req.TxHash)).Count(&existing).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if existing > 0 {
+				c.JSON(http.StatusConflict, gin.H{"error": "transaction already used for an engagement"})
+				return
+			}
+		}
+
+		if err := guard.CheckWallet(c.Request.Context(), req.UserAddress); err != nil {
+			if errors.Is(err, ErrWalletCriteria) {
+				c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+				return
+			}
+			c.JSON(http.StatusBadGateway, gin.H{"error": "failed to check wallet: " + err.Error()})
+			return
+		}
+
+		// Actions such as "use" must be proven by a transaction
+		if rule, ok := cfg.Poe.Rule(req.Action); ok && rule.RequireTx && req.TxHash == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "action " + req.Action + " requires a transaction hash"})
+			return
+		}
+
+		// Verify the backing transaction, if any, before applying the rules
+		verified := false
+		if req.TxHash != "" {
+			err := utils.VerifyInteraction(c.Request.Context(), cfg.RpcUrl, req.TxHash, req.UserAddress, app.ContractAddresses)
+			if errors.Is(err, utils.ErrTxRejected) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusBadGateway, gin.H{"error": "failed to verify transaction: " + err.Error()})
+				return
+			}
+			verified = true
 		}
 
 		// Create the engagement point
 		point := storage.Point{
 			AppID:       req.AppID,
-			UserAddress: req.UserAddress,
-			Amount:      points,
+			UserAddress: utils.NormalizeAddress(req.UserAddress),
 			Action:      req.Action,
-			TxHash:      req.TxHash,
+			TxHash:      strings.ToLower(req.TxHash),
+			Verified:    verified,
 		}
 
-		if err := db.Create(&point).Error; err != nil {
+		// Consume the nonce, apply the rules and record the point together.
+		// The nonce insert takes SQLite's write lock first, so concurrent
+		// engagements are evaluated one at a time and cannot exceed the
+		// cooldown or daily cap.
+		var award *Award
+		err = db.Transaction(func(tx *gorm.DB) error {
+			nonce := storage.EngagementNonce{UserAddress: strings.ToLower(req.UserAddress), Nonce: req.Nonce}
+			if err := tx.Create(&nonce).Error; err != nil {
+				return err
+			}
+
+			// Determine points based on the configured rules
+			var err error
+			award, err = NewRulesEngine(tx, cfg).Evaluate(req.AppID, req.UserAddress, req.Action, verified, now)
+			if err != nil {
+				return err
+			}
+			point.Amount = award.Points
+
+			if err := tx.Create(&point).Error; err != nil {
+				return err
+			}
+			return storage.RecordPoint(tx, &point)
+		})
+		switch {
+		case errors.Is(err, ErrUnknownAction):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown action: " + req.Action})
+			return
+		case errors.Is(err, ErrTxRequired):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, ErrCooldown):
+			retryAfter := int(math.Ceil(award.RetryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error(), "retryAfter": retryAfter})
+			return
+		case errors.Is(err, ErrDailyCap):
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+			return
+		}
+		if storage.IsUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "engagement already recorded"})
+			return
+		}
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log engagement"})
 			return
 		}
@@ -92,32 +215,154 @@ func logEngagement(db *storage.DB) gin.HandlerFunc {
 	}
 }
 
-// getLeaderboard returns the top users by POE points
+// getRules returns the allowed engagement actions and how they are rewarded
+func getRules(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"rules": cfg.Poe.AllRules()})
+	}
+}
+
+// getLeaderboard returns a page of users ranked by POE points. See
+// parseLeaderboardFilter for the scoping parameters; passing address also
+// returns that address's position even when it is not on the page.
 func getLeaderboard(db *storage.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Query for total points by user
-		type UserPoints struct {
-			UserAddress string `json:"userAddress"`
-			Total       int    `json:"total"`
-		}
-
-		var userPoints []UserPoints
-		if err := db.Raw(`
-			SELECT user_address, SUM(amount) as total
-			FROM points
-			GROUP BY user_address
-			ORDER BY total DESC
-			LIMIT 100
-		`).Scan(&userPoints).Error; err != nil {
+		filter, season, ok := leaderboardFilter(c, db)
+		if !ok {
+			return
+		}
+
+		page, pageSize, offset := utils.ParsePagination(c)
+		address := c.Query("address")
+
+		// Closed seasons are served from their frozen snapshot unless the
+		// request narrows them further
+		snapshot := season != nil && season.Closed && filter.AppID == 0 && filter.Tag == "" && filter.Action == ""
+
+		var standings []Standing
+		var total int64
+		var position *Standing
+		var err error
+		if snapshot {
+			standings, total, err = snapshotPage(db, season.ID, offset, pageSize)
+			if err == nil && address != "" {
+				position, err = snapshotPosition(db, season.ID, address)
+			}
+		} else {
+			standings, total, err = leaderboardPage(db.DB, filter, offset, pageSize)
+			if err == nil && address != "" {
+				position, err = leaderboardPosition(db.DB, filter, address)
+			}
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		response := gin.H{
+			"leaderboard": standings,
+			"season":      season,
+			"filter":      filter,
+			"pagination":  utils.PaginationMeta(total, page, pageSize),
+		}
+		if address != "" {
+			response["position"] = position
+		}
+
+		c.JSON(http.StatusOK, response)
+	}
+}
+
+// leaderboardFilter parses the filter and writes an error response if invalid
+func leaderboardFilter(c *gin.Context, db *storage.DB) (LeaderboardFilter, *storage.Season, bool) {
+	filter, season, err := parseLeaderboardFilter(c, db)
+	switch {
+	case errors.Is(err, errNoSeason), errors.Is(err, errSeasonNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return filter, nil, false
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return filter, nil, false
+	}
+	return filter, season, true
+}
+
+// getUserPosition returns an address's leaderboard position together with a
+// breakdown of its points by action and by app
+func getUserPosition(db *storage.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address := c.Param("address")
+		if !common.IsHexAddress(address) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address"})
+			return
+		}
+
+		filter, season, ok := leaderboardFilter(c, db)
+		if !ok {
+			return
+		}
+
+		position, err := leaderboardPosition(db.DB, filter, address)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var ranked int64
+		if err := db.Table("(?) AS ranked", rankedPoints(db.DB, filter)).Count(&ranked).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"leaderboard": userPoints})
+		user := filter.apply(db.Model(&storage.Point{})).Where("LOWER(points.user_address) = ?", strings.ToLower(address))
+
+		type ActionBreakdown struct {
+			Action string `json:"action"`
+			Total  int    `json:"total"`
+			Count  int    `json:"count"`
+		}
+		var byAction []ActionBreakdown
+		if err := user.Session(&gorm.Session{}).
+			Select("points.action AS action, SUM(points.amount) AS total, COUNT(*) AS count").
+			Group("points.action").
+			Order("total DESC").
+			Scan(&byAction).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		type AppBreakdown struct {
+			AppID   uint   `json:"appId"`
+			AppName string `json:"appName"`
+			Total   int    `json:"total"`
+			Count   int    `json:"count"`
+		}
+		var byApp []AppBreakdown
+		if err := user.Session(&gorm.Session{}).
+			Select("points.app_id AS app_id, apps.name AS app_name, SUM(points.amount) AS total, COUNT(*) AS count").
+			Joins("LEFT JOIN apps ON apps.id = points.app_id").
+			Group("points.app_id, apps.name").
+			Order("total DESC").
+			Limit(50).
+			Scan(&byApp).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"userAddress": address,
+			"position":    position,
+			"rankedUsers": ranked,
+			"byAction":    byAction,
+			"byApp":       byApp,
+			"season":      season,
+			"filter":      filter,
+		})
 	}
 }
 
-// getAppContributions returns the top contributors for a specific app
+// getAppContributions returns a page of the top contributors for a specific
+// app, accepting the same window and action filters as the leaderboard
 func getAppContributions(db *storage.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		appID, err := strconv.ParseUint(c.Param("appId"), 10, 64)
@@ -133,21 +378,15 @@ func getAppContributions(db *storage.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Query for total points by user for this app
-		type UserContribution struct {
-			UserAddress string `json:"userAddress"`
-			Total       int    `json:"total"`
+		filter, season, ok := leaderboardFilter(c, db)
+		if !ok {
+			return
 		}
+		filter.AppID = uint(appID)
 
-		var contributions []UserContribution
-		if err := db.Raw(`
-			SELECT user_address, SUM(amount) as total
-			FROM points
-			WHERE app_id = ?
-			GROUP BY user_address
-			ORDER BY total DESC
-			LIMIT 50
-		`, appID).Scan(&contributions).Error; err != nil {
+		page, pageSize, offset := utils.ParsePagination(c)
+		contributions, total, err := leaderboardPage(db.DB, filter, offset, pageSize)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -156,6 +395,8 @@ func getAppContributions(db *storage.DB) gin.HandlerFunc {
 			"appId":         appID,
 			"appName":       app.Name,
 			"contributions": contributions,
+			"season":        season,
+			"pagination":    utils.PaginationMeta(total, page, pageSize),
 		})
 	}
 }
@@ -0,0 +1,219 @@
+package poe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/blockvantage/chain-app-store/backend/storage"
+	"github.com/blockvantage/chain-app-store/backend/utils"
+)
+
+// LeaderboardFilter scopes a leaderboard query over the points table. Zero
+// values leave the corresponding dimension unfiltered.
+type LeaderboardFilter struct {
+	From   time.Time
+	To     time.Time
+	AppID  uint
+	Tag    string
+	Action string
+}
+
+// windows are the preset time windows accepted by the window parameter
+var windows = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// MarshalJSON reports only the dimensions the filter constrains
+func (f LeaderboardFilter) MarshalJSON() ([]byte, error) {
+	out := make(map[string]interface{})
+	if !f.From.IsZero() {
+		out["from"] = f.From.UTC()
+	}
+	if !f.To.IsZero() {
+		out["to"] = f.To.UTC()
+	}
+	if f.AppID != 0 {
+		out["appId"] = f.AppID
+	}
+	if f.Tag != "" {
+		out["tag"] = f.Tag
+	}
+	if f.Action != "" {
+		out["action"] = f.Action
+	}
+	return json.Marshal(out)
+}
+
+// apply adds the filter conditions to a query over the points table. The
+// time bounds are compared in UTC; see utcTimeLayout.
+func (f LeaderboardFilter) apply(query *gorm.DB) *gorm.DB {
+	if !f.From.IsZero() {
+		query = query.Where("strftime('%Y-%m-%d %H:%M:%f', points.created_at) >= ?", f.From.UTC().Format(utcTimeLayout))
+	}
+	if !f.To.IsZero() {
+		query = query.Where("strftime('%Y-%m-%d %H:%M:%f', points.created_at) < ?", f.To.UTC().Format(utcTimeLayout))
+	}
+	if f.AppID != 0 {
+		query = query.Where("points.app_id = ?", f.AppID)
+	}
+	if f.Tag != "" {
+		query = query.Where("points.app_id IN (SELECT apps.id FROM apps, json_each(apps.tags) WHERE json_each.value = ?)", f.Tag)
+	}
+	if f.Action != "" {
+		query = query.Where("points.action = ?", f.Action)
+	}
+	return query
+}
+
+// parseLeaderboardFilter reads the season, window, from, to, app, tag and
+// action query parameters. It returns the selected season, if any; without
+// a season or window the running season is used when there is one.
+func parseLeaderboardFilter(c *gin.Context, db *storage.DB) (LeaderboardFilter, *storage.Season, error) {
+	var f LeaderboardFilter
+	now := time.Now()
+
+	if app := c.Query("app"); app != "" {
+		appID, err := strconv.ParseUint(app, 10, 64)
+		if err != nil {
+			return f, nil, errors.New("app must be an app ID")
+		}
+		f.AppID = uint(appID)
+	}
+	f.Tag = c.Query("tag")
+	f.Action = c.Query("action")
+
+	window := c.Query("window")
+	if window == "" && (c.Query("from") != "" || c.Query("to") != "") {
+		window = "custom"
+	}
+
+	switch window {
+	case "":
+	case "all":
+		return f, nil, nil
+	case "custom":
+		from, err := time.Parse(time.RFC3339, c.Query("from"))
+		if err != nil {
+			return f, nil, errors.New("custom window requires from as an RFC3339 time")
+		}
+		f.From, f.To = from, now
+		if to := c.Query("to"); to != "" {
+			if f.To, err = time.Parse(time.RFC3339, to); err != nil {
+				return f, nil, errors.New("to must be an RFC3339 time")
+			}
+		}
+		if !f.To.After(f.From) {
+			return f, nil, errors.New("to must be after from")
+		}
+	default:
+		d, ok := windows[window]
+		if !ok {
+			return f, nil, fmt.Errorf("window must be one of 24h, 7d, 30d, custom or all")
+		}
+		f.From = now.Add(-d)
+	}
+
+	scope := c.Query("season")
+	if window != "" {
+		if scope != "" && scope != "all" {
+			return f, nil, errors.New("season and window cannot be combined")
+		}
+		return f, nil, nil
+	}
+
+	var season *storage.Season
+	switch scope {
+	case "all":
+	case "", "current":
+		current, err := currentSeason(db, now)
+		if err != nil {
+			return f, nil, err
+		}
+		if current == nil && scope == "current" {
+			return f, nil, errNoSeason
+		}
+		season = current
+	default:
+		seasonID, err := strconv.ParseUint(scope, 10, 64)
+		if err != nil {
+			return f, nil, errors.New("season must be all, current or a season ID")
+		}
+		season = &storage.Season{}
+		if err := db.First(season, seasonID).Error; err != nil {
+			return f, nil, errSeasonNotFound
+		}
+	}
+
+	if season != nil {
+		f.From, f.To = season.StartsAt, seasonEnd(*season)
+	}
+	return f, season, nil
+}
+
+var (
+	errNoSeason       = errors.New("no season is running")
+	errSeasonNotFound = errors.New("season not found")
+)
+
+// rankedPoints returns a subquery of user totals ranked by points, with ties
+// sharing a rank. All-time queries read the maintained aggregate tables.
+func rankedPoints(db *gorm.DB, f LeaderboardFilter) *gorm.DB {
+	var totals *gorm.DB
+	switch {
+	case f == LeaderboardFilter{}:
+		totals = db.Model(&storage.UserPointTotal{}).Select("user_address, total").Where("total > 0")
+	case f == LeaderboardFilter{AppID: f.AppID}:
+		totals = db.Model(&storage.AppUserPointTotal{}).Select("user_address, total").Where("app_id = ? AND total > 0", f.AppID)
+	default:
+		totals = f.apply(db.Model(&storage.Point{}).
+			Select("points.user_address AS user_address, SUM(points.amount) AS total").
+			Group("points.user_address"))
+	}
+
+	return db.Table("(?) AS totals", totals).
+		Select("user_address, total, RANK() OVER (ORDER BY total DESC) AS rank")
+}
+
+// leaderboardPage returns one page of standings and the number of ranked users
+func leaderboardPage(db *gorm.DB, f LeaderboardFilter, offset, limit int) ([]Standing, int64, error) {
+	ranked := rankedPoints(db, f)
+
+	var total int64
+	if err := db.Table("(?) AS ranked", ranked).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var standings []Standing
+	if err := db.Table("(?) AS ranked", ranked).
+		Order("rank ASC, user_address ASC").
+		Offset(offset).
+		Limit(limit).
+		Scan(&standings).Error; err != nil {
+		return nil, 0, err
+	}
+	return standings, total, nil
+}
+
+// leaderboardPosition returns an address's standing, or nil if it has no points
+func leaderboardPosition(db *gorm.DB, f LeaderboardFilter, address string) (*Standing, error) {
+	var standings []Standing
+	if err := db.Table("(?) AS ranked", rankedPoints(db, f)).
+		Where("user_address = ?", utils.NormalizeAddress(address)).
+		Order("rank ASC").
+		Limit(1).
+		Scan(&standings).Error; err != nil {
+		return nil, err
+	}
+	if len(standings) == 0 {
+		return nil, nil
+	}
+	return &standings[0], nil
+}
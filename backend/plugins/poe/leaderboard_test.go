@@ -0,0 +1,42 @@
+package poe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/blockvantage/chain-app-store/backend/storage"
+)
+
+// minus5 stands in for a server that stores times in a zone behind UTC
+var minus5 = time.FixedZone("UTC-5", -5*60*60)
+
+func TestComputeStandingsWindowComparesInstants(t *testing.T) {
+	db, _ := newPoeTestDB(t, nil)
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 10, 8, 0, 0, 0, 0, time.UTC)
+
+	for _, p := range []struct {
+		user string
+		at   time.Time
+	}{
+		{"0xinside", from.Add(time.Hour).In(minus5)},
+		{"0xinside", to.Add(-time.Hour).In(plus5)},
+		// Stored as 2026-10-01 04:30+05:00, which sorts inside as text
+		{"0xbefore", from.Add(-30 * time.Minute).In(plus5)},
+		// Stored as 2026-10-07 20:00-05:00, which sorts inside as text
+		{"0xafter", to.Add(time.Hour).In(minus5)},
+		{"0xafter", to},
+	} {
+		point := storage.Point{AppID: 1, UserAddress: p.user, Amount: 1, Action: "visit"}
+		point.CreatedAt = p.at
+		createPoint(t, db, point)
+	}
+
+	standings, err := computeStandings(db.DB, from, to, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(standings) != 1 || standings[0].UserAddress != "0xinside" || standings[0].Total != 2 {
+		t.Fatalf("standings = %+v, want only 0xinside with 2 points", standings)
+	}
+}
@@ -0,0 +1,453 @@
+package poe
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/blockvantage/chain-app-store/backend/storage"
+	"github.com/blockvantage/chain-app-store/backend/utils"
+)
+
+// Reward formulas
+const (
+	FormulaProportional = "proportional"
+	FormulaTiered       = "tiered"
+	FormulaCapped       = "capped"
+)
+
+// RewardParams controls how a budget is split between engagers
+type RewardParams struct {
+	Formula string       `json:"formula"`
+	Tiers   []RewardTier `json:"tiers,omitempty"` // tiered formula
+	Cap     string       `json:"cap,omitempty"`   // capped formula: max base units per address
+	// MinPoints excludes addresses with fewer points
+	MinPoints int `json:"minPoints,omitempty"`
+	// MaxRecipients keeps only the top N addresses
+	MaxRecipients int `json:"maxRecipients,omitempty"`
+}
+
+// RewardTier gives the ranks up to MaxRank a share of the budget in basis
+// points, split equally between the addresses in the tier. Each tier starts
+// where the previous one ended.
+type RewardTier struct {
+	MaxRank  int `json:"maxRank"`
+	ShareBps int `json:"shareBps"`
+}
+
+type recipient struct {
+	Account common.Address
+	Points  int
+}
+
+// rewardRecipients merges standings by checksummed address, drops entries
+// that cannot claim on-chain and applies the recipient filters
+func rewardRecipients(standings []Standing, params RewardParams) []recipient {
+	totals := make(map[common.Address]int)
+	for _, s := range standings {
+		if !common.IsHexAddress(s.UserAddress) || s.Total <= 0 {
+			continue
+		}
+		totals[common.HexToAddress(s.UserAddress)] += s.Total
+	}
+
+	recipients := make([]recipient, 0, len(totals))
+	for account, points := range totals {
+		if points < params.MinPoints {
+			continue
+		}
+		recipients = append(recipients, recipient{Account: account, Points: points})
+	}
+
+	sort.Slice(recipients, func(i, j int) bool {
+		if recipients[i].Points != recipients[j].Points {
+			return recipients[i].Points > recipients[j].Points
+		}
+		return recipients[i].Account.Hex() < recipients[j].Account.Hex()
+	})
+
+	if params.MaxRecipients > 0 && len(recipients) > params.MaxRecipients {
+		recipients = recipients[:params.MaxRecipients]
+	}
+	return recipients
+}
+
+// allocateRewards splits budget between recipients (ordered by rank).
+// Amounts are rounded down, so the sum never exceeds the budget.
+func allocateRewards(recipients []recipient, budget *big.Int, params RewardParams) ([]*big.Int, error) {
+	amounts := make([]*big.Int, len(recipients))
+	for i := range amounts {
+		amounts[i] = new(big.Int)
+	}
+	if len(recipients) == 0 {
+		return amounts, nil
+	}
+
+	switch params.Formula {
+	case FormulaProportional:
+		all := make([]int, len(recipients))
+		for i := range all {
+			all[i] = i
+		}
+		splitProportionally(recipients, all, budget, amounts)
+
+	case FormulaTiered:
+		if len(params.Tiers) == 0 {
+			return nil, errors.New("tiered formula requires tiers")
+		}
+		totalBps, prevRank := 0, 0
+		for _, tier := range params.Tiers {
+			if tier.MaxRank <= prevRank || tier.ShareBps < 0 {
+				return nil, errors.New("tiers must have increasing maxRank and non-negative shareBps")
+			}
+			totalBps += tier.ShareBps
+
+			lo, hi := prevRank, tier.MaxRank
+			prevRank = tier.MaxRank
+			if lo >= len(recipients) {
+				continue
+			}
+			if hi > len(recipients) {
+				hi = len(recipients)
+			}
+
+			tierBudget := new(big.Int).Mul(budget, big.NewInt(int64(tier.ShareBps)))
+			tierBudget.Quo(tierBudget, big.NewInt(10000))
+			each := new(big.Int).Quo(tierBudget, big.NewInt(int64(hi-lo)))
+			for i := lo; i < hi; i++ {
+				amounts[i].Set(each)
+			}
+		}
+		if totalBps > 10000 {
+			return nil, errors.New("tier shares exceed 10000 bps")
+		}
+
+	case FormulaCapped:
+		limit, ok := new(big.Int).SetString(params.Cap, 10)
+		if !ok || limit.Sign() <= 0 {
+			return nil, errors.New("capped formula requires a positive cap")
+		}
+
+		// Water-fill: anyone whose proportional share exceeds the cap gets
+		// the cap, and the excess is re-split among the rest
+		remaining := new(big.Int).Set(budget)
+		open := make([]int, len(recipients))
+		for i := range open {
+			open[i] = i
+		}
+		for len(open) > 0 {
+			splitProportionally(recipients, open, remaining, amounts)
+
+			var still []int
+			capped := false
+			for _, i := range open {
+				if amounts[i].Cmp(limit) >= 0 {
+					amounts[i].Set(limit)
+					remaining.Sub(remaining, limit)
+					capped = true
+				} else {
+					still = append(still, i)
+				}
+			}
+			if !capped {
+				break
+			}
+			open = still
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown formula %q", params.Formula)
+	}
+
+	return amounts, nil
+}
+
+// splitProportionally assigns budget to the given recipients by points
+func splitProportionally(recipients []recipient, idx []int, budget *big.Int, amounts []*big.Int) {
+	total := big.NewInt(0)
+	for _, i := range idx {
+		total.Add(total, big.NewInt(int64(recipients[i].Points)))
+	}
+	if total.Sign() == 0 {
+		return
+	}
+	for _, i := range idx {
+		share := new(big.Int).Mul(budget, big.NewInt(int64(recipients[i].Points)))
+		amounts[i].Quo(share, total)
+	}
+}
+
+// createDistribution computes allocations and their Merkle tree (admin only)
+func createDistribution(db *storage.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			RewardParams
+			Name        string    `json:"name" binding:"required"`
+			SeasonID    *uint     `json:"seasonId"`
+			From        time.Time `json:"from"`
+			To          time.Time `json:"to"`
+			Budget      string    `json:"budget" binding:"required"`
+			TokenSymbol string    `json:"tokenSymbol" binding:"required"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if req.Formula == "" {
+			req.Formula = FormulaProportional
+		}
+
+		budget, ok := new(big.Int).SetString(req.Budget, 10)
+		if !ok || budget.Sign() <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "budget must be a positive integer in base units"})
+			return
+		}
+
+		// Rank engagers over the season or time window
+		var standings []Standing
+		var err error
+		from, to := req.From.UTC(), req.To.UTC()
+		switch {
+		case req.SeasonID != nil:
+			var season storage.Season
+			if err := db.First(&season, *req.SeasonID).Error; err != nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "season not found"})
+				return
+			}
+			from, to = season.StartsAt, seasonEnd(season)
+			standings, err = seasonStandings(db, season, 0)
+		case !req.From.IsZero() && !req.To.IsZero() && req.To.After(req.From):
+			standings, err = computeStandings(db.DB, from, to, 0)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "seasonId or a from/to window is required"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		recipients := rewardRecipients(standings, req.RewardParams)
+		amounts, err := allocateRewards(recipients, budget, req.RewardParams)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Build the claims, indexed by rank, skipping zero amounts
+		var allocations []storage.RewardAllocation
+		var leaves []common.Hash
+		total := big.NewInt(0)
+		for i, r := range recipients {
+			if amounts[i].Sign() == 0 {
+				continue
+			}
+			index := uint64(len(allocations))
+			leaves = append(leaves, utils.DistributorLeaf(index, r.Account, amounts[i]))
+			allocations = append(allocations, storage.RewardAllocation{
+				Index:   index,
+				Account: r.Account.Hex(),
+				Points:  r.Points,
+				Amount:  amounts[i].String(),
+			})
+			total.Add(total, amounts[i])
+		}
+		if len(allocations) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "no eligible recipients"})
+			return
+		}
+
+		tree := utils.NewMerkleTree(leaves)
+		for i := range allocations {
+			proof, err := tree.Proof(leaves[i])
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			allocations[i].Proof = make([]string, len(proof))
+			for j, p := range proof {
+				allocations[i].Proof[j] = p.Hex()
+			}
+		}
+
+		params, _ := json.Marshal(req.RewardParams)
+		distribution := storage.RewardDistribution{
+			Name:           req.Name,
+			SeasonID:       req.SeasonID,
+			From:           from,
+			To:             to,
+			Formula:        req.Formula,
+			Params:         string(params),
+			TokenSymbol:    req.TokenSymbol,
+			Budget:         budget.String(),
+			TotalAllocated: total.String(),
+			Recipients:     len(allocations),
+			MerkleRoot:     tree.Root().Hex(),
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&distribution).Error; err != nil {
+				return err
+			}
+			for i := range allocations {
+				allocations[i].DistributionID = distribution.ID
+			}
+			if err := tx.CreateInBatches(&allocations, 500).Error; err != nil {
+				return err
+			}
+			return storage.RecordAudit(tx, c, "distribution.create", "distribution", distribution.ID, nil, distribution)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save distribution"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"distribution":  distribution,
+			"undistributed": new(big.Int).Sub(budget, total).String(),
+		})
+	}
+}
+
+// getDistributions lists reward distributions, newest first
+func getDistributions(db *storage.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var distributions []storage.RewardDistribution
+		if err := db.Order("created_at DESC").Find(&distributions).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"distributions": distributions})
+	}
+}
+
+// loadDistribution fetches the distribution named by the :id parameter
+func loadDistribution(c *gin.Context, db *storage.DB) (*storage.RewardDistribution, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid distribution ID"})
+		return nil, false
+	}
+
+	var distribution storage.RewardDistribution
+	if err := db.First(&distribution, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "distribution not found"})
+		return nil, false
+	}
+	return &distribution, true
+}
+
+// getDistribution returns a distribution's root and a page of its allocations
+func getDistribution(db *storage.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		distribution, ok := loadDistribution(c, db)
+		if !ok {
+			return
+		}
+
+		page, pageSize, offset := utils.ParsePagination(c)
+
+		var allocations []storage.RewardAllocation
+		if err := db.Where("distribution_id = ?", distribution.ID).
+			Order("`index` ASC").
+			Offset(offset).
+			Limit(pageSize).
+			Find(&allocations).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"distribution": distribution,
+			"allocations":  allocations,
+			"pagination":   utils.PaginationMeta(int64(distribution.Recipients), page, pageSize),
+		})
+	}
+}
+
+// getDistributionProof returns the claim and Merkle proof for an address
+func getDistributionProof(db *storage.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		distribution, ok := loadDistribution(c, db)
+		if !ok {
+			return
+		}
+
+		address := c.Param("address")
+		if !common.IsHexAddress(address) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address"})
+			return
+		}
+
+		var allocation storage.RewardAllocation
+		if err := db.Where("distribution_id = ? AND account = ?", distribution.ID, common.HexToAddress(address).Hex()).
+			First(&allocation).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "address has no allocation in this distribution"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"merkleRoot": distribution.MerkleRoot,
+			"index":      allocation.Index,
+			"account":    allocation.Account,
+			"amount":     allocation.Amount,
+			"proof":      allocation.Proof,
+		})
+	}
+}
+
+// exportDistribution returns the distribution in the claims JSON format used
+// by MerkleDistributor deployment scripts. Only approved distributions can be
+// exported.
+func exportDistribution(db *storage.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		distribution, ok := loadDistribution(c, db)
+		if !ok {
+			return
+		}
+
+		// The claims file funds the payout, so it needs multisig approval
+		if !distribution.Approved {
+			c.JSON(http.StatusConflict, gin.H{"error": "distribution must be approved through a distribution.approve proposal before export"})
+			return
+		}
+
+		var allocations []storage.RewardAllocation
+		if err := db.Where("distribution_id = ?", distribution.ID).Order("`index` ASC").Find(&allocations).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		type claim struct {
+			Index  uint64   `json:"index"`
+			Amount string   `json:"amount"`
+			Proof  []string `json:"proof"`
+		}
+		claims := make(map[string]claim, len(allocations))
+		for _, a := range allocations {
+			amount, _ := new(big.Int).SetString(a.Amount, 10)
+			claims[a.Account] = claim{Index: a.Index, Amount: hexutil.EncodeBig(amount), Proof: a.Proof}
+		}
+
+		total, _ := new(big.Int).SetString(distribution.TotalAllocated, 10)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=distribution-%d.json", distribution.ID))
+		c.JSON(http.StatusOK, gin.H{
+			"merkleRoot": distribution.MerkleRoot,
+			"tokenTotal": hexutil.EncodeBig(total),
+			"claims":     claims,
+		})
+	}
+}
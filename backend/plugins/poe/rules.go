@@ -0,0 +1,115 @@
+package poe
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/blockvantage/chain-app-store/backend/config"
+	"github.com/blockvantage/chain-app-store/backend/storage"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrUnknownAction is returned for actions not defined in the rules
+	ErrUnknownAction = errors.New("unknown action")
+	// ErrCooldown is returned while an action is still cooling down
+	ErrCooldown = errors.New("action is on cooldown")
+	// ErrDailyCap is returned once the daily point cap is reached
+	ErrDailyCap = errors.New("daily point cap reached for this action")
+	// ErrTxRequired is returned when an action needs a verified transaction
+	ErrTxRequired = errors.New("action requires a verified transaction")
+)
+
+// utcTimeLayout matches SQLite's strftime('%Y-%m-%d %H:%M:%f'), which
+// converts stored times to UTC whatever zone they were written in. Times
+// compared against it must be formatted in UTC too, since the comparison
+// is on text.
+const utcTimeLayout = "2006-01-02 15:04:05.000"
+
+// Award is the outcome of evaluating an engagement against the rules
+type Award struct {
+	Points     int
+	Multiplier float64
+	RetryAfter time.Duration // set with ErrCooldown
+}
+
+// RulesEngine evaluates engagements against the configured POE rules
+type RulesEngine struct {
+	db    *gorm.DB
+	rules config.PoeConfig
+}
+
+// NewRulesEngine creates a rules engine for the configured rules. Pass the
+// transaction that records the point, so the cooldown and daily cap see
+// every point committed before it.
+func NewRulesEngine(db *gorm.DB, cfg *config.Config) *RulesEngine {
+	return &RulesEngine{db: db, rules: cfg.Poe}
+}
+
+// Evaluate returns the points to award for an engagement. verifiedTx reports
+// whether the engagement is backed by a transaction confirmed on-chain.
+func (e *RulesEngine) Evaluate(appID uint, userAddress, action string, verifiedTx bool, now time.Time) (*Award, error) {
+	rule, ok := e.rules.Rule(action)
+	if !ok {
+		return nil, ErrUnknownAction
+	}
+	if rule.RequireTx && !verifiedTx {
+		return nil, ErrTxRequired
+	}
+
+	award := &Award{Points: rule.Points, Multiplier: 1}
+	if verifiedTx && rule.TxMultiplier > 1 {
+		award.Multiplier = rule.TxMultiplier
+		award.Points = int(math.Round(float64(rule.Points) * rule.TxMultiplier))
+	}
+
+	user := strings.ToLower(userAddress)
+
+	// Enforce the per-user per-app cooldown
+	if rule.CooldownSeconds > 0 {
+		cooldown := time.Duration(rule.CooldownSeconds) * time.Second
+
+		var last storage.Point
+		err := e.db.Where("app_id = ? AND LOWER(user_address) = ? AND action = ? AND strftime('%Y-%m-%d %H:%M:%f', created_at) > ?",
+			appID, user, action, now.Add(-cooldown).UTC().Format(utcTimeLayout)).
+			Order("created_at DESC").
+			Limit(1).
+			Find(&last).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to check cooldown: %w", err)
+		}
+		if last.ID != 0 {
+			award.RetryAfter = last.CreatedAt.Add(cooldown).Sub(now)
+			return award, ErrCooldown
+		}
+	}
+
+	// Enforce the daily cap, awarding whatever is left of it
+	if rule.DailyCap > 0 {
+		y, m, d := now.UTC().Date()
+		dayStart := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+		var earned int64
+		err := e.db.Model(&storage.Point{}).
+			Select("COALESCE(SUM(amount), 0)").
+			Where("LOWER(user_address) = ? AND action = ? AND strftime('%Y-%m-%d %H:%M:%f', created_at) >= ?",
+				user, action, dayStart.Format(utcTimeLayout)).
+			Scan(&earned).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to check daily cap: %w", err)
+		}
+
+		remaining := rule.DailyCap - int(earned)
+		if remaining <= 0 {
+			return award, ErrDailyCap
+		}
+		if award.Points > remaining {
+			award.Points = remaining
+		}
+	}
+
+	return award, nil
+}
@@ -0,0 +1,112 @@
+package poe
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blockvantage/chain-app-store/backend/config"
+	"github.com/blockvantage/chain-app-store/backend/storage"
+)
+
+// plus5 stands in for a server that stores times in a zone ahead of UTC
+var plus5 = time.FixedZone("UTC+5", 5*60*60)
+
+func newPoeTestDB(t *testing.T, rules map[string]config.PoeRule) (*storage.DB, *config.Config) {
+	cfg := &config.Config{
+		EnableModules: config.ModulesConfig{Poe: true},
+		Poe:           config.PoeConfig{Rules: rules},
+	}
+	db, err := storage.InitDB(filepath.Join(t.TempDir(), "poe.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.RunMigrations(db, cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db, cfg
+}
+
+func createPoint(t *testing.T, db *storage.DB, point storage.Point) {
+	if err := db.Create(&point).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEvaluateCooldownComparesInstants(t *testing.T) {
+	db, cfg := newPoeTestDB(t, map[string]config.PoeRule{
+		"visit": {Points: 1, CooldownSeconds: 60},
+	})
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	user := "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+
+	// Stored as 16:59:30+05:00, which sorts after the UTC bound as text
+	// but is outside the cooldown
+	old := storage.Point{AppID: 1, UserAddress: user, Amount: 1, Action: "visit"}
+	old.CreatedAt = now.Add(-90 * time.Second).In(plus5)
+	createPoint(t, db, old)
+
+	if _, err := NewRulesEngine(db.DB, cfg).Evaluate(1, user, "visit", false, now); err != nil {
+		t.Fatalf("Evaluate after the cooldown: %v", err)
+	}
+
+	recent := storage.Point{AppID: 1, UserAddress: user, Amount: 1, Action: "visit"}
+	recent.CreatedAt = now.Add(-30 * time.Second).In(plus5)
+	createPoint(t, db, recent)
+
+	award, err := NewRulesEngine(db.DB, cfg).Evaluate(1, user, "visit", false, now)
+	if !errors.Is(err, ErrCooldown) {
+		t.Fatalf("Evaluate within the cooldown: got %v, want ErrCooldown", err)
+	}
+	if award.RetryAfter != 30*time.Second {
+		t.Errorf("RetryAfter = %v, want 30s", award.RetryAfter)
+	}
+}
+
+func TestEvaluateDailyCapUsesUTCDay(t *testing.T) {
+	db, cfg := newPoeTestDB(t, map[string]config.PoeRule{
+		"share": {Points: 5, DailyCap: 10},
+	})
+	now := time.Date(2026, 10, 18, 2, 0, 0, 0, time.UTC)
+	user := "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+
+	// Yesterday in UTC, though the stored local date is today
+	yesterday := storage.Point{AppID: 1, UserAddress: user, Amount: 10, Action: "share"}
+	yesterday.CreatedAt = time.Date(2026, 10, 17, 23, 30, 0, 0, time.UTC).In(plus5)
+	createPoint(t, db, yesterday)
+
+	award, err := NewRulesEngine(db.DB, cfg).Evaluate(1, user, "share", false, now)
+	if err != nil {
+		t.Fatalf("Evaluate with yesterday's points: %v", err)
+	}
+	if award.Points != 5 {
+		t.Errorf("Points = %d, want 5", award.Points)
+	}
+
+	// Today in UTC, recorded under a differently cased address
+	today := storage.Point{AppID: 2, UserAddress: "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23", Amount: 8, Action: "share"}
+	today.CreatedAt = time.Date(2026, 10, 18, 1, 0, 0, 0, time.UTC).In(plus5)
+	createPoint(t, db, today)
+
+	award, err = NewRulesEngine(db.DB, cfg).Evaluate(1, user, "share", false, now)
+	if err != nil {
+		t.Fatalf("Evaluate under the cap: %v", err)
+	}
+	if award.Points != 2 {
+		t.Errorf("Points = %d, want what is left of the cap (2)", award.Points)
+	}
+
+	topUp := storage.Point{AppID: 1, UserAddress: user, Amount: 2, Action: "share"}
+	topUp.CreatedAt = now.Add(-time.Minute)
+	createPoint(t, db, topUp)
+
+	if _, err := NewRulesEngine(db.DB, cfg).Evaluate(1, user, "share", false, now); !errors.Is(err, ErrDailyCap) {
+		t.Fatalf("Evaluate at the cap: got %v, want ErrDailyCap", err)
+	}
+}
@@ -0,0 +1,290 @@
+package poe
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/blockvantage/chain-app-store/backend/storage"
+)
+
+// Standing is a user's position on a leaderboard
+type Standing struct {
+	Rank        int    `json:"rank"`
+	UserAddress string `json:"userAddress"`
+	Total       int    `json:"total"`
+}
+
+var quarterPattern = regexp.MustCompile(`^(\d{4})-Q([1-4])$`)
+
+// quarterBounds returns the start and end of a calendar quarter such as 2025-Q3
+func quarterBounds(quarter string) (time.Time, time.Time, error) {
+	m := quarterPattern.FindStringSubmatch(quarter)
+	if m == nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid quarter %q, expected YYYY-Qn", quarter)
+	}
+	year, _ := strconv.Atoi(m[1])
+	q, _ := strconv.Atoi(m[2])
+
+	start := time.Date(year, time.Month((q-1)*3+1), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 3, 0), nil
+}
+
+// seasonEnd returns when a season stops counting points
+func seasonEnd(season storage.Season) time.Time {
+	if season.ClosedAt != nil && season.ClosedAt.Before(season.EndsAt) {
+		return *season.ClosedAt
+	}
+	return season.EndsAt
+}
+
+// currentSeason returns the open season covering now, if any
+func currentSeason(db *storage.DB, now time.Time) (*storage.Season, error) {
+	var season storage.Season
+	err := db.Where("closed = ? AND starts_at <= ? AND ends_at > ?", false, now.UTC(), now.UTC()).
+		Order("starts_at DESC").
+		First(&season).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &season, nil
+}
+
+// computeStandings ranks users by points earned in [from, to). A zero from
+// or to leaves that side of the window open.
+func computeStandings(db *gorm.DB, from, to time.Time, limit int) ([]Standing, error) {
+	query := db.Table("(?) AS ranked", rankedPoints(db, LeaderboardFilter{From: from, To: to})).
+		Order("rank ASC, user_address ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var standings []Standing
+	if err := query.Scan(&standings).Error; err != nil {
+		return nil, err
+	}
+	return standings, nil
+}
+
+// seasonStandings returns the leaderboard of a season, reading the frozen
+// snapshot once the season is closed
+func seasonStandings(db *storage.DB, season storage.Season, limit int) ([]Standing, error) {
+	if !season.Closed {
+		return computeStandings(db.DB, season.StartsAt, seasonEnd(season), limit)
+	}
+
+	var rows []storage.SeasonStanding
+	query := db.Where("season_id = ?", season.ID).Order("rank ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	standings := make([]Standing, 0, len(rows))
+	for _, row := range rows {
+		standings = append(standings, Standing{Rank: row.Rank, UserAddress: row.UserAddress, Total: row.Total})
+	}
+	return standings, nil
+}
+
+// snapshotPage returns one page of a closed season's frozen standings
+func snapshotPage(db *storage.DB, seasonID uint, offset, limit int) ([]Standing, int64, error) {
+	query := db.Model(&storage.SeasonStanding{}).Where("season_id = ?", seasonID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var standings []Standing
+	if err := query.Select("rank, user_address, total").
+		Order("rank ASC, user_address ASC").
+		Offset(offset).
+		Limit(limit).
+		Scan(&standings).Error; err != nil {
+		return nil, 0, err
+	}
+	return standings, total, nil
+}
+
+// snapshotPosition returns an address's frozen standing in a closed season
+func snapshotPosition(db *storage.DB, seasonID uint, address string) (*Standing, error) {
+	var standings []Standing
+	if err := db.Model(&storage.SeasonStanding{}).
+		Select("rank, user_address, total").
+		Where("season_id = ? AND LOWER(user_address) = ?", seasonID, strings.ToLower(address)).
+		Limit(1).
+		Scan(&standings).Error; err != nil {
+		return nil, err
+	}
+	if len(standings) == 0 {
+		return nil, nil
+	}
+	return &standings[0], nil
+}
+
+// getSeasons lists all seasons, newest first
+func getSeasons(db *storage.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var seasons []storage.Season
+		if err := db.Order("starts_at DESC").Find(&seasons).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		current, err := currentSeason(db, time.Now())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"seasons": seasons, "current": current})
+	}
+}
+
+// openSeason creates a new season (admin only). The window is either given
+// explicitly or derived from a calendar quarter.
+func openSeason(db *storage.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Name     string    `json:"name"`
+			Quarter  string    `json:"quarter"`
+			StartsAt time.Time `json:"startsAt"`
+			EndsAt   time.Time `json:"endsAt"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if req.Quarter != "" {
+			start, end, err := quarterBounds(req.Quarter)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			req.StartsAt, req.EndsAt = start, end
+			if req.Name == "" {
+				req.Name = req.Quarter
+			}
+		}
+
+		if req.StartsAt.IsZero() || req.EndsAt.IsZero() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "either quarter or startsAt and endsAt are required"})
+			return
+		}
+		if !req.EndsAt.After(req.StartsAt) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "endsAt must be after startsAt"})
+			return
+		}
+		if req.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+			return
+		}
+
+		// Only one season can be open at a time
+		var open int64
+		if err := db.Model(&storage.Season{}).Where("closed = ?", false).Count(&open).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if open > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "another season is still open"})
+			return
+		}
+
+		season := storage.Season{
+			Name:     req.Name,
+			StartsAt: req.StartsAt.UTC(),
+			EndsAt:   req.EndsAt.UTC(),
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&season).Error; err != nil {
+				return err
+			}
+			return storage.RecordAudit(tx, c, "season.open", "season", season.ID, nil, season)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create season"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, season)
+	}
+}
+
+// closeSeason closes a season and freezes its final standings (admin only)
+func closeSeason(db *storage.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		seasonID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid season ID"})
+			return
+		}
+
+		var season storage.Season
+		if err := db.First(&season, seasonID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "season not found"})
+			return
+		}
+		if season.Closed {
+			c.JSON(http.StatusConflict, gin.H{"error": "season is already closed"})
+			return
+		}
+
+		now := time.Now().UTC()
+		var count int
+		err = db.Transaction(func(tx *gorm.DB) error {
+			end := season.EndsAt
+			if now.Before(end) {
+				end = now
+			}
+
+			standings, err := computeStandings(tx, season.StartsAt, end, 0)
+			if err != nil {
+				return err
+			}
+			count = len(standings)
+
+			rows := make([]storage.SeasonStanding, 0, len(standings))
+			for _, s := range standings {
+				rows = append(rows, storage.SeasonStanding{
+					SeasonID:    season.ID,
+					Rank:        s.Rank,
+					UserAddress: s.UserAddress,
+					Total:       s.Total,
+				})
+			}
+			if len(rows) > 0 {
+				if err := tx.CreateInBatches(&rows, 500).Error; err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Model(&season).Updates(map[string]interface{}{"closed": true, "closed_at": now}).Error; err != nil {
+				return err
+			}
+			return storage.RecordAudit(tx, c, "season.close", "season", season.ID,
+				gin.H{"closed": false}, gin.H{"closed": true, "closedAt": now, "standings": count})
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to close season: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"season": season, "standings": count})
+	}
+}
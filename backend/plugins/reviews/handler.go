@@ -1,6 +1,7 @@
 package reviews
 
 import (
+	"fmt"
 	"net/http"
 	"strconv"
 
@@ -18,14 +19,21 @@ type Plugin struct{}
 // RegisterRoutes registers the reviews plugin routes
 func RegisterRoutes(router *gin.RouterGroup, db *storage.DB, cfg *config.Config) error {
 	// Register routes
-	router.POST("/review", createReview(db))
+	router.POST("/review", createReview(db, cfg))
+	router.POST("/review/verify", verifyReview(db, cfg))
+	router.GET("/review/:id/signature", verifyReviewSignature(db))
+	router.GET("/review/:id/history", getReviewHistory(db))
 	router.GET("/reviews/:appId", getAppReviews(db))
+	router.GET("/apps/:id/ratings", getAppRatings(db))
+	router.POST("/review/reply", replyToReview(db))
+	router.POST("/review/vote", voteReview(db))
 	
 	// Admin routes for review moderation
 	admin := router.Group("/admin")
-	admin.Use(storage.AdminAuthMiddleware(cfg))
+	admin.Use(storage.AdminAuthMiddleware(db, storage.PermHideContent))
 	{
-		admin.POST("/review/hide", hideReview(db))
+		admin.POST("/review/hide", storage.LimitDirectHides(db, cfg), hideReview(db))
+		admin.POST("/review/reply/hide", storage.LimitDirectHides(db, cfg), hideReply(db))
 	}
 	
 	return nil
@@ -33,18 +41,23 @@ func RegisterRoutes(router *gin.RouterGroup, db *storage.DB, cfg *config.Config)
 
 // Migrate runs the migrations for the reviews plugin
 func Migrate(db *gorm.DB) error {
-	return db.AutoMigrate(&storage.Review{})
+	return db.AutoMigrate(&storage.Review{}, &storage.ReviewRevision{}, &storage.ReviewReply{}, &storage.ReviewVote{}, &storage.AppRatingStat{})
 }
 
-// createReview handles the creation of a new review
-func createReview(db *storage.DB) gin.HandlerFunc {
+// createReview handles the creation of a new review, or a new revision of the
+// reviewer's existing review. Every revision is kept in the edit history. The
+// reviewer is marked verified when txHash or their engagement history shows
+// they have used the app.
+func createReview(db *storage.DB, cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req struct {
 			AppID       uint   `json:"appId" binding:"required"`
 			UserAddress string `json:"userAddress" binding:"required"`
 			Rating      int    `json:"rating" binding:"required,min=1,max=5"`
 			Comment     string `json:"comment"`
+			Revision    int    `json:"revision" binding:"required,min=1"`
 			Signature   string `json:"signature" binding:"required"`
+			TxHash      string `json:"txHash"`
 		}
 
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -52,14 +65,6 @@ func createReview(db *storage.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Verify the signature
-		message := "Review app " + strconv.Itoa(int(req.AppID)) + " with rating " + strconv.Itoa(req.Rating)
-		valid, err := utils.VerifySignature(req.UserAddress, req.Signature, message)
-		if err != nil || !valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
-			return
-		}
-
 		// Check if the app exists
 		var app storage.App
 		if err := db.First(&app, req.AppID).Error; err != nil {
@@ -68,15 +73,72 @@ func createReview(db *storage.DB) gin.HandlerFunc {
 		}
 
 		// Check if the user has already reviewed this app
+		reviewer := utils.NormalizeAddress(req.UserAddress)
 		var existingReview storage.Review
-		result := db.Where("app_id = ? AND user_address = ?", req.AppID, req.UserAddress).First(&existingReview)
+		result := db.Where("app_id = ? AND user_address = ?", req.AppID, reviewer).First(&existingReview)
+
+		// Each edit signs the next revision number, so an older signature
+		// cannot be replayed to restore a previous version
+		expected := 1
+		if result.Error == nil {
+			expected = existingReview.Revision + 1
+		}
+		if req.Revision != expected {
+			c.JSON(http.StatusConflict, gin.H{"error": "expected revision " + strconv.Itoa(expected), "revision": expected})
+			return
+		}
+
+		// Verify the signature covers the full review
+		message := reviewMessage(req.AppID, req.Rating, req.Revision, req.Comment)
+		valid, err := utils.VerifySignature(req.UserAddress, req.Signature, message)
+		if err != nil || !valid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+			return
+		}
+
+		// Check whether the reviewer has used the app, unless an earlier
+		// version of the review was already verified
+		verified := result.Error == nil && existingReview.Verified && req.TxHash == ""
+		if !verified {
+			verified, err = verifyReviewer(c.Request.Context(), db, cfg, app, req.UserAddress, req.TxHash)
+			if err != nil && req.TxHash != "" {
+				respondVerifyError(c, err)
+				return
+			}
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
 		if result.Error == nil {
 			// Update the existing review
+			before := existingReview
 			existingReview.Rating = req.Rating
 			existingReview.Comment = req.Comment
 			existingReview.Signature = req.Signature
+			existingReview.Revision = req.Revision
+			existingReview.Verified = verified
+			if req.TxHash != "" {
+				existingReview.VerifiedTxHash = req.TxHash
+			}
 
-			if err := db.Save(&existingReview).Error; err != nil {
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Save(&existingReview).Error; err != nil {
+					return err
+				}
+				// Keep the legacy version, which predates the edit history
+				if before.Revision == 0 {
+					if err := tx.Create(newRevision(before)).Error; err != nil {
+						return err
+					}
+				}
+				if err := tx.Create(newRevision(existingReview)).Error; err != nil {
+					return err
+				}
+				return storage.ApplyReviewChange(tx, &before, &existingReview)
+			})
+			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update review"})
 				return
 			}
@@ -87,15 +149,27 @@ func createReview(db *storage.DB) gin.HandlerFunc {
 
 		// Create a new review
 		review := storage.Review{
-			AppID:       req.AppID,
-			UserAddress: req.UserAddress,
-			Rating:      req.Rating,
-			Comment:     req.Comment,
-			Signature:   req.Signature,
-			Hidden:      false,
+			AppID:          req.AppID,
+			UserAddress:    reviewer,
+			Rating:         req.Rating,
+			Comment:        req.Comment,
+			Signature:      req.Signature,
+			Revision:       req.Revision,
+			Hidden:         false,
+			Verified:       verified,
+			VerifiedTxHash: req.TxHash,
 		}
 
-		if err := db.Create(&review).Error; err != nil {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&review).Error; err != nil {
+				return err
+			}
+			if err := tx.Create(newRevision(review)).Error; err != nil {
+				return err
+			}
+			return storage.ApplyReviewChange(tx, nil, &review)
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create review"})
 			return
 		}
@@ -104,7 +178,9 @@ func createReview(db *storage.DB) gin.HandlerFunc {
 	}
 }
 
-// getAppReviews returns all reviews for an app
+// getAppReviews returns a page of visible reviews for an app, ordered by the
+// sort parameter (helpful, newest, highest or lowest). verified=true limits
+// the page to verified reviewers.
 func getAppReviews(db *storage.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		appID, err := strconv.ParseUint(c.Param("appId"), 10, 64)
@@ -120,28 +196,53 @@ func getAppReviews(db *storage.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Get all visible reviews for the app
+		sort := c.DefaultQuery("sort", SortHelpful)
+		order, ok := sortOrders[sort]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be helpful, newest, highest or lowest"})
+			return
+		}
+
+		onlyVerified := c.Query("verified") == "true"
+
+		page, pageSize, offset := utils.ParsePagination(c)
+
+		// Get a page of visible reviews for the app
+		query := db.Preload("Reply", "hidden = ?", false).
+			Where("app_id = ? AND hidden = ?", appID, false)
+		if onlyVerified {
+			query = query.Where("verified = ?", true)
+		}
 		var reviews []storage.Review
-		if err := db.Where("app_id = ? AND hidden = ?", appID, false).Find(&reviews).Error; err != nil {
+		if err := query.
+			Order(order).
+			Offset(offset).
+			Limit(pageSize).
+			Find(&reviews).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		// Calculate average rating
-		var totalRating int
-		for _, review := range reviews {
-			totalRating += review.Rating
+		// Read the average from the maintained rating stats
+		stat, err := storage.GetRatingStat(db.DB, app.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
 
-		var avgRating float64
-		if len(reviews) > 0 {
-			avgRating = float64(totalRating) / float64(len(reviews))
+		total := stat.RatingCount
+		if onlyVerified {
+			total = stat.VerifiedCount
 		}
 
 		c.JSON(http.StatusOK, gin.H{
-			"reviews":     reviews,
-			"count":       len(reviews),
-			"avgRating":   avgRating,
+			"reviews":           reviews,
+			"count":             stat.RatingCount,
+			"avgRating":         stat.Average(),
+			"verifiedCount":     stat.VerifiedCount,
+			"verifiedAvgRating": stat.VerifiedAverage(),
+			"sort":              sort,
+			"pagination":        utils.PaginationMeta(int64(total), page, pageSize),
 		})
 	}
 }
@@ -165,7 +266,19 @@ func hideReview(db *storage.DB) gin.HandlerFunc {
 			return
 		}
 
-		if err := db.Model(&review).Update("hidden", req.Hidden).Error; err != nil {
+		before := review
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&review).Update("hidden", req.Hidden).Error; err != nil {
+				return err
+			}
+			review.Hidden = req.Hidden
+			if err := storage.RecordAudit(tx, c, "review.hide", storage.FlagTypeReview, review.ID,
+				gin.H{"hidden": before.Hidden}, gin.H{"hidden": review.Hidden}); err != nil {
+				return err
+			}
+			return storage.ApplyReviewChange(tx, &before, &review)
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update review"})
 			return
 		}
@@ -173,3 +286,120 @@ func hideReview(db *storage.DB) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"success": true})
 	}
 }
+
+// replyMessage builds the text a developer signs to reply to a review
+func replyMessage(reviewID uint, revision int, comment string) string {
+	return fmt.Sprintf("Reply to review %d\nRevision: %d\nComment:\n%s", reviewID, revision, comment)
+}
+
+// replyToReview posts or edits the developer's reply to a review. Only the
+// app's developer address may reply.
+func replyToReview(db *storage.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			ReviewID         uint   `json:"reviewId" binding:"required"`
+			DeveloperAddress string `json:"developerAddress" binding:"required"`
+			Comment          string `json:"comment" binding:"required,max=2000"`
+			Revision         int    `json:"revision" binding:"required,min=1"`
+			Signature        string `json:"signature" binding:"required"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Verify the signature covers the full reply
+		valid, err := utils.VerifySignature(req.DeveloperAddress, req.Signature, replyMessage(req.ReviewID, req.Revision, req.Comment))
+		if err != nil || !valid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+			return
+		}
+
+		var review storage.Review
+		if err := db.First(&review, req.ReviewID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "review not found"})
+			return
+		}
+
+		var app storage.App
+		if err := db.First(&app, review.AppID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "app not found"})
+			return
+		}
+
+		if !utils.SameAddress(app.DeveloperAddress, req.DeveloperAddress) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only the app developer can reply to reviews"})
+			return
+		}
+
+		// Edit the existing reply if there is one
+		var reply storage.ReviewReply
+		result := db.Where("review_id = ?", review.ID).Limit(1).Find(&reply)
+		if result.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+			return
+		}
+
+		// Each edit signs the next revision number, so the published
+		// signature of an older reply cannot be replayed to revert an edit
+		if expected := reply.Revision + 1; req.Revision != expected {
+			c.JSON(http.StatusConflict, gin.H{"error": "expected revision " + strconv.Itoa(expected), "revision": expected})
+			return
+		}
+
+		status := http.StatusCreated
+		if reply.ID != 0 {
+			status = http.StatusOK
+		}
+
+		reply.ReviewID = review.ID
+		reply.DeveloperAddress = req.DeveloperAddress
+		reply.Comment = req.Comment
+		reply.Signature = req.Signature
+
+		reply.Revision = req.Revision
+
+		if err := db.Save(&reply).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save reply"})
+			return
+		}
+
+		c.JSON(status, reply)
+	}
+}
+
+// hideReply hides or unhides a developer reply (admin only)
+func hideReply(db *storage.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			ReplyID uint `json:"replyId" binding:"required"`
+			Hidden  bool `json:"hidden"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var reply storage.ReviewReply
+		if err := db.First(&reply, req.ReplyID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "reply not found"})
+			return
+		}
+
+		before := gin.H{"hidden": reply.Hidden}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&reply).Update("hidden", req.Hidden).Error; err != nil {
+				return err
+			}
+			return storage.RecordAudit(tx, c, "reply.hide", storage.FlagTypeReply, reply.ID, before, gin.H{"hidden": req.Hidden})
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update reply"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	}
+}
@@ -0,0 +1,139 @@
+package reviews
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/blockvantage/chain-app-store/backend/storage"
+	"github.com/blockvantage/chain-app-store/backend/utils"
+)
+
+// reviewMessage builds the text a reviewer signs for a revision of a review
+func reviewMessage(appID uint, rating, revision int, comment string) string {
+	return fmt.Sprintf("Review app %d with rating %d\nRevision: %d\nComment:\n%s", appID, rating, revision, comment)
+}
+
+// legacyReviewMessage is the text signed by reviews from before comments were
+// covered, which have revision 0
+func legacyReviewMessage(appID uint, rating int) string {
+	return "Review app " + strconv.Itoa(int(appID)) + " with rating " + strconv.Itoa(rating)
+}
+
+// newRevision records the current content of a review as a history entry
+func newRevision(review storage.Review) *storage.ReviewRevision {
+	return &storage.ReviewRevision{
+		ReviewID:  review.ID,
+		Revision:  review.Revision,
+		Rating:    review.Rating,
+		Comment:   review.Comment,
+		Signature: review.Signature,
+	}
+}
+
+// SignatureCheck is the result of checking a review's content against its
+// signature. It includes the exact signed message so that anyone can repeat
+// the check.
+type SignatureCheck struct {
+	Revision      int    `json:"revision"`
+	Rating        int    `json:"rating"`
+	Comment       string `json:"comment"`
+	Message       string `json:"message"`
+	Signature     string `json:"signature"`
+	Signer        string `json:"signer,omitempty"` // Address recovered from the signature
+	Valid         bool   `json:"valid"`            // Signer is the reviewer
+	CoversComment bool   `json:"coversComment"`    // False for legacy signatures over app and rating only
+}
+
+// checkSignature verifies that the reviewer signed the given content
+func checkSignature(review storage.Review, revision, rating int, comment, signature string) SignatureCheck {
+	check := SignatureCheck{
+		Revision:      revision,
+		Rating:        rating,
+		Comment:       comment,
+		Signature:     signature,
+		CoversComment: revision > 0,
+	}
+	if revision > 0 {
+		check.Message = reviewMessage(review.AppID, rating, revision, comment)
+	} else {
+		check.Message = legacyReviewMessage(review.AppID, rating)
+	}
+
+	signer, err := utils.RecoverAddressFromSignature(signature, check.Message)
+	if err == nil {
+		check.Signer = signer
+		check.Valid = utils.SameAddress(signer, review.UserAddress)
+	}
+	return check
+}
+
+// loadVisibleReview loads a review by the :id parameter, treating hidden
+// reviews as missing
+func loadVisibleReview(c *gin.Context, db *storage.DB) (storage.Review, bool) {
+	var review storage.Review
+	reviewID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid review ID"})
+		return review, false
+	}
+	if err := db.Where("hidden = ?", false).First(&review, reviewID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "review not found"})
+		return review, false
+	}
+	return review, true
+}
+
+// verifyReviewSignature checks that the review as displayed is exactly what
+// the reviewer's wallet signed
+func verifyReviewSignature(db *storage.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		review, ok := loadVisibleReview(c, db)
+		if !ok {
+			return
+		}
+
+		check := checkSignature(review, review.Revision, review.Rating, review.Comment, review.Signature)
+		c.JSON(http.StatusOK, gin.H{
+			"reviewId":    review.ID,
+			"appId":       review.AppID,
+			"userAddress": review.UserAddress,
+			"check":       check,
+		})
+	}
+}
+
+// getReviewHistory returns every signed revision of a review, newest first,
+// each with its signature check
+func getReviewHistory(db *storage.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		review, ok := loadVisibleReview(c, db)
+		if !ok {
+			return
+		}
+
+		var revisions []storage.ReviewRevision
+		if err := db.Where("review_id = ?", review.ID).Order("revision DESC").Find(&revisions).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		history := make([]gin.H, 0, len(revisions))
+		for _, r := range revisions {
+			history = append(history, gin.H{
+				"createdAt": r.CreatedAt,
+				"check":     checkSignature(review, r.Revision, r.Rating, r.Comment, r.Signature),
+			})
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"reviewId":    review.ID,
+			"appId":       review.AppID,
+			"userAddress": review.UserAddress,
+			"revision":    review.Revision,
+			"revisions":   history,
+		})
+	}
+}
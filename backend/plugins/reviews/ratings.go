@@ -0,0 +1,163 @@
+package reviews
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/blockvantage/chain-app-store/backend/storage"
+)
+
+// trendFormats maps each trend interval to the strftime format of its period
+var trendFormats = map[string]string{
+	"day":   "%Y-%m-%d",
+	"week":  "%Y-W%W",
+	"month": "%Y-%m",
+}
+
+// maxTrendPeriods caps how many trend periods one request returns
+const maxTrendPeriods = 100
+
+// RatingSplit summarizes the visible ratings of one group of reviewers
+type RatingSplit struct {
+	Count     int         `json:"count"`
+	Average   float64     `json:"average"`
+	Histogram map[int]int `json:"histogram"`
+	sum       int
+}
+
+// newRatingSplit returns a split with every star level present
+func newRatingSplit() *RatingSplit {
+	return &RatingSplit{Histogram: map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}}
+}
+
+// add counts count ratings of the given value
+func (s *RatingSplit) add(rating, count int) {
+	s.Histogram[rating] += count
+	s.Count += count
+	s.sum += rating * count
+	s.Average = float64(s.sum) / float64(s.Count)
+}
+
+// TrendPoint holds the ratings submitted in one period
+type TrendPoint struct {
+	Period            string  `json:"period"`
+	Count             int     `json:"count"`
+	Average           float64 `json:"average"`
+	VerifiedCount     int     `json:"verifiedCount"`
+	CumulativeAverage float64 `json:"cumulativeAverage"`
+}
+
+// getAppRatings returns the rating histogram, Bayesian average, verified and
+// unverified splits and the rating trend (?interval=day|week|month, ?periods=N)
+// of an app's visible reviews
+func getAppRatings(db *storage.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid app ID"})
+			return
+		}
+
+		var app storage.App
+		if err := db.First(&app, appID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "app not found"})
+			return
+		}
+
+		interval := c.DefaultQuery("interval", "month")
+		format, ok := trendFormats[interval]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "interval must be day, week or month"})
+			return
+		}
+
+		periods, err := strconv.Atoi(c.DefaultQuery("periods", "12"))
+		if err != nil || periods < 1 || periods > maxTrendPeriods {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "periods must be between 1 and " + strconv.Itoa(maxTrendPeriods)})
+			return
+		}
+
+		visible := db.Model(&storage.Review{}).Where("app_id = ? AND hidden = ?", app.ID, false)
+
+		// Build the histograms from per-star counts
+		var buckets []struct {
+			Rating   int
+			Verified bool
+			Count    int
+		}
+		if err := visible.Session(&gorm.Session{}).
+			Select("rating, verified, COUNT(*) AS count").
+			Group("rating, verified").
+			Scan(&buckets).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		all, verified, unverified := newRatingSplit(), newRatingSplit(), newRatingSplit()
+		for _, b := range buckets {
+			all.add(b.Rating, b.Count)
+			if b.Verified {
+				verified.add(b.Rating, b.Count)
+			} else {
+				unverified.add(b.Rating, b.Count)
+			}
+		}
+
+		prior, err := storage.RatingPrior(db.DB)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		stat := storage.AppRatingStat{AppID: app.ID, RatingSum: all.sum, RatingCount: all.Count}
+
+		// Group ratings by the period they were submitted in
+		var rows []struct {
+			Period        string
+			Count         int
+			Sum           int
+			VerifiedCount int
+		}
+		if err := visible.Session(&gorm.Session{}).
+			Select("strftime(?, created_at) AS period, COUNT(*) AS count, SUM(rating) AS sum, "+
+				"SUM(CASE WHEN verified THEN 1 ELSE 0 END) AS verified_count", format).
+			Group("period").
+			Order("period").
+			Scan(&rows).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		trend := make([]TrendPoint, 0, len(rows))
+		runningSum, runningCount := 0, 0
+		for _, r := range rows {
+			runningSum += r.Sum
+			runningCount += r.Count
+			trend = append(trend, TrendPoint{
+				Period:            r.Period,
+				Count:             r.Count,
+				Average:           float64(r.Sum) / float64(r.Count),
+				VerifiedCount:     r.VerifiedCount,
+				CumulativeAverage: float64(runningSum) / float64(runningCount),
+			})
+		}
+		if len(trend) > periods {
+			trend = trend[len(trend)-periods:]
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"appId":           app.ID,
+			"count":           all.Count,
+			"average":         all.Average,
+			"histogram":       all.Histogram,
+			"bayesianAverage": stat.BayesianAverage(prior),
+			"prior":           gin.H{"mean": prior, "weight": storage.RatingPriorWeight},
+			"verified":        verified,
+			"unverified":      unverified,
+			"interval":        interval,
+			"trend":           trend,
+		})
+	}
+}
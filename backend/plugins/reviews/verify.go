@@ -0,0 +1,106 @@
+package reviews
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/blockvantage/chain-app-store/backend/config"
+	"github.com/blockvantage/chain-app-store/backend/storage"
+	"github.com/blockvantage/chain-app-store/backend/utils"
+)
+
+// verifyReviewer reports whether reviewer has transacted with one of the
+// app's contracts. A txHash is checked over RPC; without one the reviewer's
+// indexed history of tx-verified engagement points for the app is used.
+func verifyReviewer(ctx context.Context, db *storage.DB, cfg *config.Config, app storage.App, reviewer, txHash string) (bool, error) {
+	if txHash != "" {
+		if err := utils.VerifyInteraction(ctx, cfg.RpcUrl, txHash, reviewer, app.ContractAddresses); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	if !db.Migrator().HasTable(&storage.Point{}) {
+		return false, nil
+	}
+
+	var count int64
+	err := db.Model(&storage.Point{}).
+		Where("app_id = ? AND LOWER(user_address) = LOWER(?) AND verified = ?", app.ID, reviewer, true).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// verifyReview marks an existing review as verified by a transaction the
+// reviewer sent to one of the app's contracts. The transaction sender proves
+// the reviewer's identity, so no signature is needed.
+func verifyReview(db *storage.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			ReviewID uint   `json:"reviewId" binding:"required"`
+			TxHash   string `json:"txHash" binding:"required"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var review storage.Review
+		if err := db.First(&review, req.ReviewID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "review not found"})
+			return
+		}
+
+		// Banned reviewers cannot verify their reviews
+		if !storage.EnforceBans(c, db, storage.BanTargets{Wallets: []string{review.UserAddress}}) {
+			return
+		}
+
+		var app storage.App
+		if err := db.First(&app, review.AppID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "app not found"})
+			return
+		}
+
+		if _, err := verifyReviewer(c.Request.Context(), db, cfg, app, review.UserAddress, req.TxHash); err != nil {
+			respondVerifyError(c, err)
+			return
+		}
+
+		before := review
+		review.Verified = true
+		review.VerifiedTxHash = req.TxHash
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&review).Updates(map[string]interface{}{
+				"verified":         true,
+				"verified_tx_hash": req.TxHash,
+			}).Error; err != nil {
+				return err
+			}
+			return storage.ApplyReviewChange(tx, &before, &review)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update review"})
+			return
+		}
+
+		c.JSON(http.StatusOK, review)
+	}
+}
+
+// respondVerifyError writes the response for a failed reviewer verification
+func respondVerifyError(c *gin.Context, err error) {
+	if errors.Is(err, utils.ErrTxRejected) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadGateway, gin.H{"error": "failed to verify transaction: " + err.Error()})
+}
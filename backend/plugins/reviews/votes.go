@@ -0,0 +1,167 @@
+package reviews
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/blockvantage/chain-app-store/backend/storage"
+	"github.com/blockvantage/chain-app-store/backend/utils"
+)
+
+// Review sort modes
+const (
+	SortHelpful = "helpful"
+	SortNewest  = "newest"
+	SortHighest = "highest"
+	SortLowest  = "lowest"
+)
+
+// sortOrders maps each sort mode to its ORDER BY clause. Ties fall back to
+// newest first so pages are stable.
+var sortOrders = map[string]string{
+	SortHelpful: "helpful_score DESC, created_at DESC, id DESC",
+	SortNewest:  "created_at DESC, id DESC",
+	SortHighest: "rating DESC, helpful_score DESC, created_at DESC, id DESC",
+	SortLowest:  "rating ASC, helpful_score DESC, created_at DESC, id DESC",
+}
+
+// wilsonScore returns the lower bound of the 95% Wilson confidence interval
+// for the share of helpful votes. A single helpful vote scores well below
+// many mostly-helpful votes, so one-off votes cannot dominate the ranking.
+func wilsonScore(helpful, unhelpful int) float64 {
+	n := float64(helpful + unhelpful)
+	if n == 0 {
+		return 0
+	}
+
+	const z = 1.96
+	p := float64(helpful) / n
+	score := (p + z*z/(2*n) - z*math.Sqrt((p*(1-p)+z*z/(4*n))/n)) / (1 + z*z/n)
+	return math.Max(score, 0)
+}
+
+// errVoteRevision is returned when a vote does not sign the next revision
+var errVoteRevision = errors.New("stale vote revision")
+
+// voteMessage builds the text a user signs to vote on a review
+func voteMessage(reviewID uint, helpful bool, revision int) string {
+	verdict := "unhelpful"
+	if helpful {
+		verdict = "helpful"
+	}
+	return fmt.Sprintf("Vote review %d as %s\nRevision: %d", reviewID, verdict, revision)
+}
+
+// voteReview records a signed helpful or unhelpful vote. Each address has
+// one vote per review; voting again replaces the previous vote.
+func voteReview(db *storage.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			ReviewID     uint   `json:"reviewId" binding:"required"`
+			VoterAddress string `json:"voterAddress" binding:"required"`
+			Helpful      *bool  `json:"helpful" binding:"required"`
+			Revision     int    `json:"revision" binding:"required,min=1"`
+			Signature    string `json:"signature" binding:"required"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Verify the signature
+		valid, err := utils.VerifySignature(req.VoterAddress, req.Signature, voteMessage(req.ReviewID, *req.Helpful, req.Revision))
+		if err != nil || !valid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+			return
+		}
+
+		var review storage.Review
+		if err := db.Where("hidden = ?", false).First(&review, req.ReviewID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "review not found"})
+			return
+		}
+
+		if utils.SameAddress(review.UserAddress, req.VoterAddress) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "cannot vote on your own review"})
+			return
+		}
+
+		voter := utils.NormalizeAddress(req.VoterAddress)
+		expected := 0
+		err = db.Transaction(func(tx *gorm.DB) error {
+			// Re-read the counters inside the transaction
+			if err := tx.First(&review, review.ID).Error; err != nil {
+				return err
+			}
+
+			var vote storage.ReviewVote
+			if err := tx.Where("review_id = ? AND voter_address = ?", review.ID, voter).Limit(1).Find(&vote).Error; err != nil {
+				return err
+			}
+
+			// Each vote signs the next revision, so an older signature
+			// cannot be replayed to flip a changed vote back
+			expected = vote.Revision + 1
+			if req.Revision != expected {
+				return errVoteRevision
+			}
+			if vote.ID != 0 && vote.Helpful == *req.Helpful {
+				return nil
+			}
+
+			helpful, unhelpful := review.HelpfulCount, review.UnhelpfulCount
+			if vote.ID != 0 {
+				// Withdraw the previous vote
+				if vote.Helpful {
+					helpful--
+				} else {
+					unhelpful--
+				}
+			}
+			if *req.Helpful {
+				helpful++
+			} else {
+				unhelpful++
+			}
+
+			vote.ReviewID = review.ID
+			vote.VoterAddress = voter
+			vote.Helpful = *req.Helpful
+			vote.Signature = req.Signature
+			vote.Revision = req.Revision
+			if err := tx.Save(&vote).Error; err != nil {
+				return err
+			}
+
+			review.HelpfulCount, review.UnhelpfulCount = helpful, unhelpful
+			review.HelpfulScore = wilsonScore(helpful, unhelpful)
+			return tx.Model(&review).Updates(map[string]interface{}{
+				"helpful_count":   review.HelpfulCount,
+				"unhelpful_count": review.UnhelpfulCount,
+				"helpful_score":   review.HelpfulScore,
+			}).Error
+		})
+		if errors.Is(err, errVoteRevision) {
+			c.JSON(http.StatusConflict, gin.H{"error": "expected revision " + strconv.Itoa(expected), "revision": expected})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record vote"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"reviewId":       review.ID,
+			"helpfulCount":   review.HelpfulCount,
+			"unhelpfulCount": review.UnhelpfulCount,
+			"helpfulScore":   review.HelpfulScore,
+		})
+	}
+}
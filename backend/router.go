@@ -55,10 +55,9 @@ func setupRouter(db *storage.DB, cfg *config.Config) *gin.Engine {
 
 		// Admin routes
 		admin := api.Group("/admin")
-		admin.Use(storage.AdminAuthMiddleware(cfg))
 		{
-			admin.POST("/feature", storage.FeatureApp(db))
-			admin.POST("/hide", storage.HideApp(db))
+			admin.POST("/feature", storage.AdminAuthMiddleware(db, storage.PermFeatureApps), storage.FeatureApp(db))
+			admin.POST("/hide", storage.AdminAuthMiddleware(db, storage.PermHideContent), storage.HideApp(db))
 		}
 	}
 
@@ -1,6 +1,7 @@
 -- Clear existing data
 DELETE FROM boosts;
 DELETE FROM app_images;
+DELETE FROM app_contracts;
 DELETE FROM apps;
 
 -- Reset auto-increment
@@ -10,7 +11,7 @@ DELETE FROM sqlite_sequence WHERE name='boosts';
 
 -- Insert apps
 INSERT INTO apps (id, name, description, logo_path, featured, hidden, developer_address, website_url, repo_url, twitter_url, discord_url, telegram_url, medium_url, github_url, tags, contract_addresses, created_at, updated_at) VALUES
-(1, 'Uniswap', 'Uniswap is the largest decentralized exchange (DEX) on Ethereum and multiple Layer-2 networks.\nLaunched in 2018 by Hayden Adams, it pioneered the Automated Market Maker (AMM) model—smart-contract liquidity pools that let anyone swap ERC-20 tokens directly from a self-custody wallet, without order books, accounts or KYC. The protocol is open-source, permissionless (any token can be listed), and secured by Ethereum.', 'logos/1749669059042842895_uniswap-uni-logo.png', 1, 0, '0x1234567890123456789012345678901234567890', 'https://app.uniswap.com', 'https://github.com/Uniswap/v3-core', 'https://x.com/uniswap/', 'https://discord.com/invite/uniswap', NULL, NULL, 'https://github.com/Uniswap/', '["DeFi"]', '["0x1F98415757620B543A52E61c46B32eB19261F984","0x1f9840a85d5af5bf1d1762f925bdaddc4201f984"]', datetime('now'), datetime('now')),
+(1, 'Uniswap', 'Uniswap is the largest decentralized exchange (DEX) on Ethereum and multiple Layer-2 networks.\nLaunched in 2018 by Hayden Adams, it pioneered the Automated Market Maker (AMM) model—smart-contract liquidity pools that let anyone swap ERC-20 tokens directly from a self-custody wallet, without order books, accounts or KYC. The protocol is open-source, permissionless (any token can be listed), and secured by Ethereum.', 'logos/1749669059042842895_uniswap-uni-logo.png', 1, 0, '0x1234567890123456789012345678901234567890', 'https://app.uniswap.com', 'https://github.com/Uniswap/v3-core', 'https://x.com/uniswap/', 'https://discord.com/invite/uniswap', NULL, NULL, 'https://github.com/Uniswap/', '["DeFi"]', '["0x1F98415757620B543A52E61c46B32eB19261F984","0x1f9840a85d5aF5bf1D1762F925BDADdC4201F984"]', datetime('now'), datetime('now')),
 
 (2, 'Aave', 'Aave is a decentralized lending protocol where users can lend and borrow cryptocurrencies. Lenders earn interest by depositing digital assets into liquidity pools. Borrowers can then use their crypto as collateral to take out flash loans using these pools.', 'logos/1749669059042842895_uniswap-uni-logo.png', 1, 0, '0x1234567890123456789012345678901234567891', 'https://app.aave.com', 'https://github.com/aave/aave-v3-core', 'https://x.com/aave/', 'https://discord.com/invite/aave', 'https://t.me/Aave_Official', NULL, 'https://github.com/aave/', '["DeFi","Lending"]', '["0x7Fc66500c84A76Ad7e9c93437bFc5Ac33E2DDaE9"]', datetime('now'), datetime('now')),
 
@@ -36,10 +37,14 @@ INSERT INTO apps (id, name, description, logo_path, featured, hidden, developer_
 
 (13, 'Polygon', 'Polygon is a protocol and framework for building and connecting Ethereum-compatible blockchain networks.', 'logos/1749669059042842895_uniswap-uni-logo.png', 1, 0, '0x1234567890123456789012345678901234567902', 'https://polygon.technology', 'https://github.com/maticnetwork', 'https://x.com/0xPolygon', 'https://discord.com/invite/polygon', 'https://t.me/polygonofficial', NULL, 'https://github.com/maticnetwork/', '["Infrastructure","Scaling"]', '["0x7D1AfA7B718fb893dB30A3aBc0Cfc608AaCfeBB0"]', datetime('now'), datetime('now')),
 
-(14, 'Gnosis Safe', 'Gnosis Safe is the most trusted platform to manage digital assets on Ethereum, offering multi-signature security and advanced access controls.', 'logos/1749669059042842895_uniswap-uni-logo.png', 0, 0, '0x1234567890123456789012345678901234567903', 'https://safe.global', 'https://github.com/safe-global', 'https://x.com/safe', 'https://discord.com/invite/safe', NULL, NULL, 'https://github.com/safe-global/', '["Infrastructure","Security"]', '["0x3e5c63644e683549055b9be8653de26e0b4cd36e"]', datetime('now'), datetime('now')),
+(14, 'Gnosis Safe', 'Gnosis Safe is the most trusted platform to manage digital assets on Ethereum, offering multi-signature security and advanced access controls.', 'logos/1749669059042842895_uniswap-uni-logo.png', 0, 0, '0x1234567890123456789012345678901234567903', 'https://safe.global', 'https://github.com/safe-global', 'https://x.com/safe', 'https://discord.com/invite/safe', NULL, NULL, 'https://github.com/safe-global/', '["Infrastructure","Security"]', '["0x3E5c63644E683549055b9Be8653de26E0B4CD36E"]', datetime('now'), datetime('now')),
 
 (15, 'Lido', 'Lido is a liquid staking solution for Ethereum, allowing users to stake their ETH while maintaining liquidity through stETH tokens.', 'logos/1749669059042842895_uniswap-uni-logo.png', 0, 0, '0x1234567890123456789012345678901234567904', 'https://lido.fi', 'https://github.com/lidofinance', 'https://x.com/lidofinance', 'https://discord.com/invite/lido', 'https://t.me/lidofinance', NULL, 'https://github.com/lidofinance/', '["DeFi","Staking"]', '["0x5A98FcBEA516Cf06857215779Fd812CA3beF1B32"]', datetime('now'), datetime('now'));
 
+-- Index contract addresses for reverse lookup
+INSERT INTO app_contracts (app_id, address)
+SELECT apps.id, contracts.value FROM apps, json_each(apps.contract_addresses) AS contracts;
+
 -- Insert mockup images for each app
 INSERT INTO app_images (app_id, filename, image_path, description, "order", created_at, updated_at) VALUES
 (1, 'uniswap-mockup-1.png', 'mockups/1749663103848762083_Screenshot 2025-06-11 at 1.33.10 AM.png', 'Uniswap trading interface', 1, datetime('now'), datetime('now')),
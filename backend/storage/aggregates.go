@@ -0,0 +1,261 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/blockvantage/chain-app-store/backend/config"
+)
+
+// RecordPoint adds a newly created point to the POE aggregate tables. It
+// must run in the same transaction as the point insert, and the point's
+// user address must be normalized with utils.NormalizeAddress.
+func RecordPoint(tx *gorm.DB, point *Point) error {
+	now := time.Now()
+
+	userTotal := UserPointTotal{UserAddress: point.UserAddress, Total: point.Amount, Count: 1, UpdatedAt: now}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_address"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"total":      gorm.Expr("total + ?", point.Amount),
+			"count":      gorm.Expr("count + 1"),
+			"updated_at": now,
+		}),
+	}).Create(&userTotal).Error; err != nil {
+		return fmt.Errorf("failed to update user point total: %w", err)
+	}
+
+	appTotal := AppUserPointTotal{AppID: point.AppID, UserAddress: point.UserAddress, Total: point.Amount, Count: 1, UpdatedAt: now}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "app_id"}, {Name: "user_address"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"total":      gorm.Expr("total + ?", point.Amount),
+			"count":      gorm.Expr("count + 1"),
+			"updated_at": now,
+		}),
+	}).Create(&appTotal).Error; err != nil {
+		return fmt.Errorf("failed to update app point total: %w", err)
+	}
+
+	return nil
+}
+
+// ratingDelta is the change a review write makes to an app's rating stats
+type ratingDelta struct {
+	sum, count, verifiedSum, verifiedCount int
+}
+
+// add adds (sign 1) or removes (sign -1) a review's contribution
+func (d *ratingDelta) add(review *Review, sign int) {
+	if review == nil || review.Hidden {
+		return
+	}
+	d.sum += sign * review.Rating
+	d.count += sign
+	if review.Verified {
+		d.verifiedSum += sign * review.Rating
+		d.verifiedCount += sign
+	}
+}
+
+// ApplyReviewChange updates an app's rating stats for a review write. before
+// is the review as it was (nil for a new review) and after is the review as
+// written. Hidden reviews do not count. It must run in the same transaction
+// as the review write.
+func ApplyReviewChange(tx *gorm.DB, before, after *Review) error {
+	var d ratingDelta
+	d.add(before, -1)
+	d.add(after, 1)
+	if d == (ratingDelta{}) {
+		return nil
+	}
+
+	appID := after.AppID
+	now := time.Now()
+	stat := AppRatingStat{
+		AppID:         appID,
+		RatingSum:     d.sum,
+		RatingCount:   d.count,
+		VerifiedSum:   d.verifiedSum,
+		VerifiedCount: d.verifiedCount,
+		UpdatedAt:     now,
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "app_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"rating_sum":     gorm.Expr("rating_sum + ?", d.sum),
+			"rating_count":   gorm.Expr("rating_count + ?", d.count),
+			"verified_sum":   gorm.Expr("verified_sum + ?", d.verifiedSum),
+			"verified_count": gorm.Expr("verified_count + ?", d.verifiedCount),
+			"updated_at":     now,
+		}),
+	}).Create(&stat).Error; err != nil {
+		return fmt.Errorf("failed to update rating stats: %w", err)
+	}
+	return nil
+}
+
+// GetRatingStat returns the rating stats of an app, zero if it has none
+func GetRatingStat(db *gorm.DB, appID uint) (AppRatingStat, error) {
+	stat := AppRatingStat{AppID: appID}
+	err := db.Where("app_id = ?", appID).Limit(1).Find(&stat).Error
+	return stat, err
+}
+
+// The queries that recompute each aggregate table from its source rows. The
+// point totals group on user_address, so rebuilds first normalize it with
+// NormalizePointAddresses.
+const (
+	userPointTotalsSQL = `SELECT user_address, SUM(amount) AS total, COUNT(*) AS count
+		FROM points WHERE deleted_at IS NULL GROUP BY user_address`
+	appUserPointTotalsSQL = `SELECT app_id, user_address, SUM(amount) AS total, COUNT(*) AS count
+		FROM points WHERE deleted_at IS NULL GROUP BY app_id, user_address`
+	appRatingStatsSQL = `SELECT app_id, SUM(rating) AS rating_sum, COUNT(*) AS rating_count,
+		SUM(CASE WHEN verified THEN rating ELSE 0 END) AS verified_sum,
+		SUM(CASE WHEN verified THEN 1 ELSE 0 END) AS verified_count
+		FROM reviews WHERE deleted_at IS NULL AND hidden = 0 GROUP BY app_id`
+)
+
+// RebuildAggregates recomputes every aggregate table of the enabled modules
+// from the source rows
+func RebuildAggregates(db *DB, cfg *config.Config) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if cfg.EnableModules.Poe {
+			if err := rebuildPointTotals(tx); err != nil {
+				return err
+			}
+		}
+		if cfg.EnableModules.Reviews {
+			if err := rebuildRatingStats(tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func rebuildPointTotals(tx *gorm.DB) error {
+	if _, err := NormalizePointAddresses(tx); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := tx.Exec("DELETE FROM user_point_totals").Error; err != nil {
+		return fmt.Errorf("failed to rebuild user point totals: %w", err)
+	}
+	if err := tx.Exec("INSERT INTO user_point_totals (user_address, total, count, updated_at) SELECT user_address, total, count, ? FROM ("+userPointTotalsSQL+")", now).Error; err != nil {
+		return fmt.Errorf("failed to rebuild user point totals: %w", err)
+	}
+	if err := tx.Exec("DELETE FROM app_user_point_totals").Error; err != nil {
+		return fmt.Errorf("failed to rebuild app point totals: %w", err)
+	}
+	if err := tx.Exec("INSERT INTO app_user_point_totals (app_id, user_address, total, count, updated_at) SELECT app_id, user_address, total, count, ? FROM ("+appUserPointTotalsSQL+")", now).Error; err != nil {
+		return fmt.Errorf("failed to rebuild app point totals: %w", err)
+	}
+	return nil
+}
+
+// NormalizePointAddresses rewrites points user addresses that are not in
+// their checksummed form, so each user's points share one address. It
+// returns the number of points changed.
+func NormalizePointAddresses(tx *gorm.DB) (int64, error) {
+	return normalizeAddresses(tx, &Point{}, "user_address")
+}
+
+func rebuildRatingStats(tx *gorm.DB) error {
+	if err := tx.Exec("DELETE FROM app_rating_stats").Error; err != nil {
+		return fmt.Errorf("failed to rebuild rating stats: %w", err)
+	}
+	if err := tx.Exec("INSERT INTO app_rating_stats (app_id, rating_sum, rating_count, verified_sum, verified_count, updated_at) SELECT app_id, rating_sum, rating_count, verified_sum, verified_count, ? FROM ("+appRatingStatsSQL+")", time.Now()).Error; err != nil {
+		return fmt.Errorf("failed to rebuild rating stats: %w", err)
+	}
+	return nil
+}
+
+// VerifyAggregates compares the aggregate tables with totals recomputed from
+// the source rows and returns a description of every mismatch
+func VerifyAggregates(db *DB, cfg *config.Config) ([]string, error) {
+	var mismatches []string
+
+	if cfg.EnableModules.Poe {
+		found, err := diffAggregate(db, "user_point_totals", userPointTotalsSQL,
+			[]string{"user_address"}, []string{"total", "count"})
+		if err != nil {
+			return nil, err
+		}
+		mismatches = append(mismatches, found...)
+
+		found, err = diffAggregate(db, "app_user_point_totals", appUserPointTotalsSQL,
+			[]string{"app_id", "user_address"}, []string{"total", "count"})
+		if err != nil {
+			return nil, err
+		}
+		mismatches = append(mismatches, found...)
+	}
+
+	if cfg.EnableModules.Reviews {
+		found, err := diffAggregate(db, "app_rating_stats", appRatingStatsSQL,
+			[]string{"app_id"}, []string{"rating_sum", "rating_count", "verified_sum", "verified_count"})
+		if err != nil {
+			return nil, err
+		}
+		mismatches = append(mismatches, found...)
+	}
+
+	return mismatches, nil
+}
+
+// diffAggregate compares an aggregate table with its source query on the
+// key columns and reports rows whose values differ. A missing row counts as
+// all zeros, so emptied aggregate rows are not reported.
+func diffAggregate(db *DB, table, source string, keys, values []string) ([]string, error) {
+	on := make([]string, len(keys))
+	key := make([]string, len(keys))
+	for i, k := range keys {
+		on[i] = fmt.Sprintf("a.%s = s.%s", k, k)
+		key[i] = fmt.Sprintf("COALESCE(a.%s, s.%s)", k, k)
+	}
+
+	stored := make([]string, len(values))
+	expected := make([]string, len(values))
+	differs := make([]string, len(values))
+	for i, v := range values {
+		stored[i] = fmt.Sprintf("COALESCE(a.%s, 0)", v)
+		expected[i] = fmt.Sprintf("COALESCE(s.%s, 0)", v)
+		differs[i] = stored[i] + " <> " + expected[i]
+	}
+
+	columns := fmt.Sprintf("%s AS key, %s AS stored, %s AS expected",
+		strings.Join(key, " || '/' || "),
+		strings.Join(stored, " || ',' || "),
+		strings.Join(expected, " || ',' || "))
+	where := strings.Join(differs, " OR ")
+	join := strings.Join(on, " AND ")
+
+	// Union both left joins to get a full outer join
+	query := fmt.Sprintf(`
+		SELECT %[1]s FROM %[2]s a LEFT JOIN (%[3]s) s ON %[4]s WHERE %[5]s
+		UNION
+		SELECT %[1]s FROM (%[3]s) s LEFT JOIN %[2]s a ON %[4]s WHERE %[5]s`,
+		columns, table, source, join, where)
+
+	rows, err := db.Raw(query).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var mismatches []string
+	for rows.Next() {
+		var k, have, want string
+		if err := rows.Scan(&k, &have, &want); err != nil {
+			return nil, err
+		}
+		mismatches = append(mismatches, fmt.Sprintf("%s[%s]: stored %s, expected %s", table, k, have, want))
+	}
+	return mismatches, rows.Err()
+}
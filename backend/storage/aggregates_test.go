@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/blockvantage/chain-app-store/backend/config"
+)
+
+func newPoeTestDB(t *testing.T) (*DB, *config.Config) {
+	cfg := &config.Config{EnableModules: config.ModulesConfig{Poe: true}}
+	db, err := InitDB(filepath.Join(t.TempDir(), "poe.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RunMigrations(db, cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db, cfg
+}
+
+const (
+	checksummed = "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+	lowercased  = "0x2c7536e3605d9c16a7a3d7b1898e529396a65c23"
+	uppercased  = "0x2C7536E3605D9C16A7A3D7B1898E529396A65C23"
+)
+
+func TestMigrationNormalizesPointAddresses(t *testing.T) {
+	db, cfg := newPoeTestDB(t)
+
+	// Points written before addresses were normalized
+	for i, address := range []string{checksummed, lowercased, uppercased} {
+		point := Point{AppID: uint(i%2 + 1), UserAddress: address, Amount: 10, Action: "visit"}
+		if err := db.Create(&point).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := RecordPoint(db.DB, &point); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := RunMigrations(db, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	var addresses []string
+	if err := db.Model(&Point{}).Distinct("user_address").Pluck("user_address", &addresses).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(addresses) != 1 || addresses[0] != checksummed {
+		t.Errorf("points addresses = %v, want only %s", addresses, checksummed)
+	}
+
+	var totals []UserPointTotal
+	if err := db.Find(&totals).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(totals) != 1 || totals[0].UserAddress != checksummed || totals[0].Total != 30 || totals[0].Count != 3 {
+		t.Errorf("user point totals = %+v, want one row of 30 points for %s", totals, checksummed)
+	}
+
+	var appTotals []AppUserPointTotal
+	if err := db.Order("app_id").Find(&appTotals).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(appTotals) != 2 || appTotals[0].Total != 20 || appTotals[1].Total != 10 {
+		t.Errorf("app user point totals = %+v, want 20 points on app 1 and 10 on app 2", appTotals)
+	}
+
+	mismatches, err := VerifyAggregates(db, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mismatches) > 0 {
+		t.Errorf("aggregates differ from points: %v", mismatches)
+	}
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/blockvantage/chain-app-store/backend/utils"
+)
+
+// auditTimeLayout matches SQLite's strftime('%Y-%m-%d %H:%M:%f'), which
+// converts stored times to UTC, so the from/to bounds compare as instants
+const auditTimeLayout = "2006-01-02 15:04:05.000"
+
+// auditTriggers reject any change to recorded audit entries
+var auditTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS audit_entries_no_update BEFORE UPDATE ON audit_entries
+		BEGIN SELECT RAISE(ABORT, 'audit log is append-only'); END`,
+	`CREATE TRIGGER IF NOT EXISTS audit_entries_no_delete BEFORE DELETE ON audit_entries
+		BEGIN SELECT RAISE(ABORT, 'audit log is append-only'); END`,
+}
+
+// AuditReasonHeader carries the admin's reason for an action
+const AuditReasonHeader = "X-Admin-Reason"
+
+// maxAuditReason caps the stored length of a reason
+const maxAuditReason = 500
+
+// RecordAudit appends an admin action to the audit log. before and after
+// hold the fields the action changed. The admin address comes from
+// AdminAuthMiddleware and the reason from the X-Admin-Reason header. It
+// should run in the same transaction as the action.
+func RecordAudit(tx *gorm.DB, c *gin.Context, action, targetType string, targetID uint, before, after interface{}) error {
+	beforeJSON, err := json.Marshal(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := json.Marshal(after)
+	if err != nil {
+		return err
+	}
+
+	reason := c.GetHeader(AuditReasonHeader)
+	if len(reason) > maxAuditReason {
+		reason = reason[:maxAuditReason]
+	}
+
+	return tx.Create(&AuditEntry{
+		AdminAddress: AdminAddress(c),
+		Action:       action,
+		TargetType:   targetType,
+		TargetID:     targetID,
+		Before:       beforeJSON,
+		After:        afterJSON,
+		Reason:       reason,
+	}).Error
+}
+
+// GetAuditLog returns a page of audit entries, newest first, filtered by
+// admin, action, targetType, targetId and a from/to RFC 3339 time range.
+// format=csv exports every matching entry instead (admin only).
+func GetAuditLog(db *DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := db.Model(&AuditEntry{})
+
+		if admin := c.Query("admin"); admin != "" {
+			query = query.Where("LOWER(admin_address) = LOWER(?)", admin)
+		}
+		if action := c.Query("action"); action != "" {
+			query = query.Where("action = ?", action)
+		}
+		if targetType := c.Query("targetType"); targetType != "" {
+			query = query.Where("target_type = ?", targetType)
+		}
+		if targetID := c.Query("targetId"); targetID != "" {
+			id, err := strconv.ParseUint(targetID, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid target ID"})
+				return
+			}
+			query = query.Where("target_id = ?", id)
+		}
+		for param, cond := range map[string]string{
+			"from": "strftime('%Y-%m-%d %H:%M:%f', created_at) >= ?",
+			"to":   "strftime('%Y-%m-%d %H:%M:%f', created_at) < ?",
+		} {
+			value := c.Query(param)
+			if value == "" {
+				continue
+			}
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": param + " must be an RFC 3339 time"})
+				return
+			}
+			query = query.Where(cond, t.UTC().Format(auditTimeLayout))
+		}
+		query = query.Order("id DESC")
+
+		if c.Query("format") == "csv" {
+			exportAuditCSV(c, query)
+			return
+		}
+
+		page, pageSize, offset := utils.ParsePagination(c)
+
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var entries []AuditEntry
+		if err := query.Offset(offset).Limit(pageSize).Find(&entries).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"entries":    entries,
+			"pagination": utils.PaginationMeta(total, page, pageSize),
+		})
+	}
+}
+
+// exportAuditCSV streams the matching audit entries as a CSV attachment.
+// Once part of the file has been sent the status cannot change, so later
+// errors end the file early and are logged.
+func exportAuditCSV(c *gin.Context, query *gorm.DB) {
+	rows, err := query.Rows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", `attachment; filename="audit-log.csv"`)
+
+	if err := writeAuditCSV(csv.NewWriter(c.Writer), query, rows); err != nil {
+		log.Printf("audit log export failed: %v", err)
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Disposition")
+			c.Writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to export audit log"})
+		}
+	}
+}
+
+// writeAuditCSV writes a header and one record per row, and reports the
+// first read or write error
+func writeAuditCSV(w *csv.Writer, query *gorm.DB, rows *sql.Rows) error {
+	if err := w.Write([]string{"id", "created_at", "admin_address", "action", "target_type", "target_id", "before", "after", "reason"}); err != nil {
+		return err
+	}
+	for rows.Next() {
+		var entry AuditEntry
+		if err := query.ScanRows(rows, &entry); err != nil {
+			return fmt.Errorf("failed to read audit entry: %w", err)
+		}
+		if err := w.Write([]string{
+			strconv.FormatUint(uint64(entry.ID), 10),
+			entry.CreatedAt.UTC().Format(time.RFC3339),
+			entry.AdminAddress,
+			entry.Action,
+			entry.TargetType,
+			strconv.FormatUint(uint64(entry.TargetID), 10),
+			string(entry.Before),
+			string(entry.After),
+			entry.Reason,
+		}); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read audit entries: %w", err)
+	}
+	w.Flush()
+	return w.Error()
+}
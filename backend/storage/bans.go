@@ -0,0 +1,343 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/blockvantage/chain-app-store/backend/utils"
+)
+
+// Ban kinds
+const (
+	BanWallet   = "wallet"
+	BanContract = "contract"
+	BanDomain   = "domain"
+)
+
+// BanError is returned when a request involves a banned value
+type BanError struct {
+	Ban Ban
+}
+
+func (e *BanError) Error() string {
+	msg := fmt.Sprintf("%s %s is banned", e.Ban.Kind, e.Ban.Value)
+	if e.Ban.Reason != "" {
+		msg += ": " + e.Ban.Reason
+	}
+	return msg
+}
+
+// BanTargets are the values of a request checked against the ban lists
+type BanTargets struct {
+	Wallets   []string
+	Contracts []string
+	URLs      []string
+}
+
+// AppBanTargets returns everything in an app listing that can be banned
+func AppBanTargets(app App) BanTargets {
+	return BanTargets{
+		Wallets:   []string{app.DeveloperAddress},
+		Contracts: app.ContractAddresses,
+		URLs: []string{app.WebsiteURL, app.RepoURL, app.TwitterURL, app.DiscordURL,
+			app.TelegramURL, app.MediumURL, app.GithubURL},
+	}
+}
+
+// normalizeBanValue returns the canonical form of a value of the given kind
+func normalizeBanValue(kind, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	switch kind {
+	case BanWallet, BanContract:
+		if !common.IsHexAddress(value) {
+			return "", fmt.Errorf("invalid %s address %q", kind, value)
+		}
+		return utils.NormalizeAddress(value), nil
+	case BanDomain:
+		host := hostOf(value)
+		if host == "" || !strings.Contains(host, ".") {
+			return "", fmt.Errorf("invalid domain %q", value)
+		}
+		return host, nil
+	}
+	return "", fmt.Errorf("kind must be %s, %s or %s", BanWallet, BanContract, BanDomain)
+}
+
+// hostOf returns the lowercase host of a URL or bare domain
+func hostOf(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+}
+
+// domainCandidates returns a host and each parent domain, so a ban on a
+// domain also covers its subdomains
+func domainCandidates(host string) []string {
+	var candidates []string
+	for host != "" {
+		candidates = append(candidates, host)
+		i := strings.Index(host, ".")
+		if i < 0 {
+			break
+		}
+		host = host[i+1:]
+	}
+	return candidates
+}
+
+// activeBans limits a query to bans that have not expired
+func activeBans(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Where("expires_at IS NULL OR expires_at > ?", now)
+}
+
+// CheckBans returns a *BanError for the first banned value in targets
+func CheckBans(db *gorm.DB, targets BanTargets) error {
+	values := map[string][]string{}
+	for _, w := range targets.Wallets {
+		if common.IsHexAddress(w) {
+			values[BanWallet] = append(values[BanWallet], utils.NormalizeAddress(w))
+		}
+	}
+	for _, a := range targets.Contracts {
+		if common.IsHexAddress(a) {
+			values[BanContract] = append(values[BanContract], utils.NormalizeAddress(a))
+		}
+	}
+	for _, u := range targets.URLs {
+		values[BanDomain] = append(values[BanDomain], domainCandidates(hostOf(u))...)
+	}
+
+	for _, kind := range []string{BanWallet, BanContract, BanDomain} {
+		if len(values[kind]) == 0 {
+			continue
+		}
+		var ban Ban
+		err := activeBans(db, time.Now()).
+			Where("kind = ? AND value IN ?", kind, values[kind]).
+			Limit(1).Find(&ban).Error
+		if err != nil {
+			return err
+		}
+		if ban.ID != 0 {
+			return &BanError{Ban: ban}
+		}
+	}
+	return nil
+}
+
+// EnforceBans rejects the request with 403 if targets include a banned
+// value, and reports whether the request may continue
+func EnforceBans(c *gin.Context, db *DB, targets BanTargets) bool {
+	err := CheckBans(db.DB, targets)
+	var banErr *BanError
+	switch {
+	case errors.As(err, &banErr):
+		c.JSON(http.StatusForbidden, gin.H{"error": banErr.Error(), "ban": banErr.Ban})
+		return false
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// banWalletFields are the JSON body fields that name the wallet acting in a
+// request
+var banWalletFields = []string{"userAddress", "developerAddress", "voterAddress", "reporterAddress"}
+
+// maxBanCheckBodyBytes bounds the JSON body BanMiddleware reads
+const maxBanCheckBodyBytes = 8 << 20
+
+// BanMiddleware rejects write requests whose JSON body names a banned wallet
+// in one of banWalletFields, so every endpoint acting for a wallet is covered
+// in one place. Field names match case-insensitively, as in request binding.
+// Bodies are read whatever their Content-Type, since ShouldBindJSON ignores
+// it too. Multipart forms are left to their handlers, and handlers that act
+// for a wallet stored with the content, such as verifications, check it
+// with EnforceBans themselves.
+func BanMiddleware(db *DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if c.Request.Body == nil || c.ContentType() == "multipart/form-data" {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxBanCheckBodyBytes+1))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if len(body) > maxBanCheckBodyBytes {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Malformed bodies are left for the handler to report
+		var fields map[string]json.RawMessage
+		if json.Unmarshal(body, &fields) != nil {
+			c.Next()
+			return
+		}
+		var wallets []string
+		for name, raw := range fields {
+			for _, field := range banWalletFields {
+				var wallet string
+				if strings.EqualFold(name, field) && json.Unmarshal(raw, &wallet) == nil {
+					wallets = append(wallets, wallet)
+				}
+			}
+		}
+		if len(wallets) > 0 && !EnforceBans(c, db, BanTargets{Wallets: wallets}) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetBans returns a page of bans, filtered by kind and by active=true|false
+// (admin only)
+func GetBans(db *DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := db.Model(&Ban{})
+		if kind := c.Query("kind"); kind != "" {
+			query = query.Where("kind = ?", kind)
+		}
+		switch c.Query("active") {
+		case "true":
+			query = activeBans(query, time.Now())
+		case "false":
+			query = query.Where("expires_at <= ?", time.Now())
+		}
+
+		page, pageSize, offset := utils.ParsePagination(c)
+
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var bans []Ban
+		if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&bans).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"bans":       bans,
+			"pagination": utils.PaginationMeta(total, page, pageSize),
+		})
+	}
+}
+
+// CreateBan bans a wallet, contract address or domain, or updates the reason
+// and expiry of an existing ban (admin only)
+func CreateBan(db *DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Kind      string     `json:"kind" binding:"required"`
+			Value     string     `json:"value" binding:"required"`
+			Reason    string     `json:"reason" binding:"required,max=500"`
+			ExpiresAt *time.Time `json:"expiresAt"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		value, err := normalizeBanValue(req.Kind, req.Value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expiresAt must be in the future"})
+			return
+		}
+
+		var ban Ban
+		status := http.StatusCreated
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Where("kind = ? AND value = ?", req.Kind, value).Limit(1).Find(&ban).Error; err != nil {
+				return err
+			}
+			before := ban
+			if ban.ID != 0 {
+				status = http.StatusOK
+			}
+
+			ban.Kind = req.Kind
+			ban.Value = value
+			ban.Reason = req.Reason
+			ban.ExpiresAt = req.ExpiresAt
+			ban.CreatedBy = AdminAddress(c)
+			if err := tx.Save(&ban).Error; err != nil {
+				return err
+			}
+
+			var auditBefore interface{}
+			if before.ID != 0 {
+				auditBefore = gin.H{"reason": before.Reason, "expiresAt": before.ExpiresAt}
+			}
+			return RecordAudit(tx, c, "ban.create", "ban", ban.ID, auditBefore, ban)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save ban"})
+			return
+		}
+
+		c.JSON(status, ban)
+	}
+}
+
+// LiftBan removes a ban (admin only)
+func LiftBan(db *DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var ban Ban
+		if err := db.First(&ban, c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "ban not found"})
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Unscoped().Delete(&ban).Error; err != nil {
+				return err
+			}
+			return RecordAudit(tx, c, "ban.lift", "ban", ban.ID, ban, nil)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to lift ban"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	}
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBanMiddlewareChecksBodiesOfAnyContentType(t *testing.T) {
+	db, _ := newLinkTestDB(t)
+
+	banned := "0x19E7E376E7C213B7E7e7e46cc70A5dD086DAff2A"
+	if err := db.Create(&Ban{Kind: BanWallet, Value: banned, Reason: "spam"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(BanMiddleware(db))
+	handled := func(c *gin.Context) {
+		var req struct {
+			UserAddress string `json:"userAddress"`
+		}
+		c.ShouldBindJSON(&req)
+		c.JSON(http.StatusOK, gin.H{"userAddress": req.UserAddress})
+	}
+	router.POST("/write", handled)
+	router.GET("/write", handled)
+
+	for _, tc := range []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		want        int
+	}{
+		{"json", http.MethodPost, "application/json", `{"userAddress":"` + banned + `"}`, http.StatusForbidden},
+		{"text/plain", http.MethodPost, "text/plain", `{"userAddress":"` + banned + `"}`, http.StatusForbidden},
+		{"no content type", http.MethodPost, "", `{"reporterAddress":"` + banned + `"}`, http.StatusForbidden},
+		{"field and address case", http.MethodPost, "text/plain", `{"UserAddress":"` + strings.ToLower(banned) + `"}`, http.StatusForbidden},
+		{"other wallet", http.MethodPost, "text/plain", `{"userAddress":"0x1563915e194D8CfBA1943570603F7606A3115508"}`, http.StatusOK},
+		{"unrelated field", http.MethodPost, "application/json", `{"recipient":"` + banned + `"}`, http.StatusOK},
+		{"malformed body", http.MethodPost, "application/json", `{"userAddress":`, http.StatusOK},
+		{"read", http.MethodGet, "application/json", `{"userAddress":"` + banned + `"}`, http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/write", strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tc.want, rec.Body)
+			}
+		})
+	}
+}
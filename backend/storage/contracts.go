@@ -0,0 +1,239 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/blockvantage/chain-app-store/backend/config"
+	"github.com/blockvantage/chain-app-store/backend/utils"
+)
+
+// maxAppContracts bounds the contract addresses of one listing, each of
+// which costs an RPC call to validate
+const maxAppContracts = 20
+
+// ContractError describes why a contract address was rejected
+type ContractError struct {
+	Address string `json:"address"`
+	Reason  string `json:"reason"`
+}
+
+func (e *ContractError) Error() string {
+	if e.Address == "" {
+		return e.Reason
+	}
+	return fmt.Sprintf("contract %s: %s", e.Address, e.Reason)
+}
+
+// ValidateContracts checks the format and checksum of each address and,
+// when an RPC endpoint is configured, that bytecode is deployed at it. It
+// returns the checksummed addresses without duplicates. Invalid addresses
+// give a *ContractError.
+func ValidateContracts(ctx context.Context, rpcURL string, addresses []string) ([]string, error) {
+	if len(addresses) > maxAppContracts {
+		return nil, &ContractError{Reason: fmt.Sprintf("at most %d contract addresses can be listed", maxAppContracts)}
+	}
+
+	seen := map[string]bool{}
+	normalized := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		checksummed, err := utils.ParseAddress(address)
+		if err != nil {
+			return nil, &ContractError{Address: address, Reason: err.Error()}
+		}
+		if seen[checksummed] {
+			continue
+		}
+		seen[checksummed] = true
+		normalized = append(normalized, checksummed)
+	}
+
+	if rpcURL == "" || len(normalized) == 0 {
+		return normalized, nil
+	}
+
+	client, err := utils.DialChain(ctx, rpcURL)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	for _, address := range normalized {
+		code, err := client.CodeAt(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+		if len(code) == 0 {
+			return nil, &ContractError{Address: address, Reason: "no contract is deployed at this address"}
+		}
+	}
+	return normalized, nil
+}
+
+// EnforceContractRules validates the contract addresses of app and replaces
+// them with their checksummed forms. Invalid or undeployed addresses are
+// rejected with 400, and RPC failures with 502. It reports whether the
+// request may continue.
+func EnforceContractRules(c *gin.Context, cfg *config.Config, app *App) bool {
+	addresses, err := ValidateContracts(c.Request.Context(), cfg.RpcUrl, app.ContractAddresses)
+	var contractErr *ContractError
+	switch {
+	case errors.As(err, &contractErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid contract address: " + contractErr.Error(), "contract": contractErr})
+		return false
+	case err != nil:
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to check contract addresses: " + err.Error()})
+		return false
+	}
+	app.ContractAddresses = addresses
+	return true
+}
+
+// SyncAppContracts makes the indexed contracts of an app match addresses.
+// Contracts still listed keep their verification, and the app's verified
+// badge is refreshed.
+func SyncAppContracts(tx *gorm.DB, appID uint, addresses []string) error {
+	listed := []string{}
+	for _, address := range addresses {
+		if common.IsHexAddress(address) {
+			listed = append(listed, utils.NormalizeAddress(address))
+		}
+	}
+
+	query := tx.Where("app_id = ?", appID)
+	if len(listed) > 0 {
+		query = query.Where("address NOT IN ?", listed)
+	}
+	if err := query.Delete(&AppContract{}).Error; err != nil {
+		return err
+	}
+
+	for _, address := range listed {
+		row := AppContract{AppID: appID, Address: address}
+		if err := tx.Where(row).FirstOrCreate(&row).Error; err != nil {
+			return err
+		}
+	}
+	return refreshAppVerified(tx, appID)
+}
+
+// IndexAppContracts checksums the stored contract addresses of every app
+// and brings the contract index in line with them. Addresses that are not
+// valid hex are left as they are and not indexed.
+func IndexAppContracts(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var apps []App
+		if err := tx.Select("id", "contract_addresses").Find(&apps).Error; err != nil {
+			return err
+		}
+		// Drop the contracts of deleted apps
+		if err := tx.Where("app_id NOT IN (?)", tx.Model(&App{}).Select("id")).Delete(&AppContract{}).Error; err != nil {
+			return err
+		}
+
+		for _, app := range apps {
+			seen := map[string]bool{}
+			addresses := make([]string, 0, len(app.ContractAddresses))
+			changed := false
+			for _, address := range app.ContractAddresses {
+				if common.IsHexAddress(address) {
+					checksummed := utils.NormalizeAddress(address)
+					changed = changed || checksummed != address
+					address = checksummed
+				}
+				if seen[address] {
+					changed = true
+					continue
+				}
+				seen[address] = true
+				addresses = append(addresses, address)
+			}
+
+			if changed {
+				data, err := json.Marshal(addresses)
+				if err != nil {
+					return err
+				}
+				err = tx.Model(&App{}).Where("id = ?", app.ID).UpdateColumn("contract_addresses", string(data)).Error
+				if err != nil {
+					return err
+				}
+			}
+			if err := SyncAppContracts(tx, app.ID, addresses); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// EnforceContractClaims rejects the request with 409 if another app holds a
+// verified claim on one of the addresses, and reports whether the request
+// may continue
+func EnforceContractClaims(c *gin.Context, db *DB, appID uint, addresses []string) bool {
+	if len(addresses) == 0 {
+		return true
+	}
+	var claim AppContract
+	err := db.Where("verified = ? AND address IN ? AND app_id <> ?", true, addresses, appID).
+		Limit(1).Find(&claim).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if claim.ID != 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": fmt.Sprintf("contract %s is claimed by the verified developer of app %d", claim.Address, claim.AppID),
+			"claim": claim,
+		})
+		return false
+	}
+	return true
+}
+
+// GetContractApp returns the listed app that a contract address belongs to,
+// which is the app with a verified claim on it or else the earliest
+// listing, along with any other visible apps that also list it
+func GetContractApp(db *DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address, err := utils.ParseAddress(c.Param("address"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address: " + err.Error()})
+			return
+		}
+
+		var apps []App
+		err = db.Joins("JOIN app_contracts ON app_contracts.app_id = apps.id").
+			Where("app_contracts.address = ? AND apps.hidden = ?", address, false).
+			Order("app_contracts.verified DESC").Order("apps.id").Find(&apps).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(apps) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no listed app uses this contract", "address": address})
+			return
+		}
+
+		var claim AppContract
+		if err := db.Where("app_id = ? AND address = ?", apps[0].ID, address).First(&claim).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"address":     address,
+			"explorerUrl": cfg.ExplorerAddressURL(address),
+			"verified":    claim.Verified,
+			"app":         apps[0],
+			"otherApps":   apps[1:],
+		})
+	}
+}
@@ -3,8 +3,10 @@ package storage
 import (
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/blockvantage/chain-app-store/backend/config"
+	"github.com/blockvantage/chain-app-store/backend/utils"
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -33,22 +35,84 @@ func InitDB(dbPath string) (*DB, error) {
 func RunMigrations(db *DB, cfg *config.Config) error {
 	log.Println("Running database migrations...")
 	
+	// Contract indexes created by this run are filled from existing apps
+	needsContractIndex := !db.Migrator().HasTable(&AppContract{})
+	// Domain badges added by this run replace contract claims made by domain proofs
+	needsDomainBadge := db.Migrator().HasTable(&App{}) && !db.Migrator().HasColumn(&App{}, "DomainVerified")
+
 	// Auto-migrate the schema
-	if err := db.AutoMigrate(&App{}, &Transaction{}, &AppImage{}, &Flag{}); err != nil {
+	if err := db.AutoMigrate(&App{}, &Transaction{}, &AppImage{}, &Flag{}, &AuditEntry{}, &AdminRole{}, &AdminNonce{}, &AdminProposal{}, &ProposalApproval{}, &Ban{}, &LinkStatus{}, &LinkCheck{}, &AppContract{}, &DeveloperProof{}); err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
+	// Only one app can hold a verified claim on a contract
+	if err := db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_app_contracts_verified ON app_contracts(address) WHERE verified").Error; err != nil {
+		return fmt.Errorf("failed to index verified contracts: %w", err)
+	}
+
+	if needsContractIndex {
+		log.Println("Indexing app contracts...")
+		if err := IndexAppContracts(db.DB); err != nil {
+			return fmt.Errorf("failed to index app contracts: %w", err)
+		}
+	}
+
+	if needsDomainBadge && !needsContractIndex {
+		if err := demoteDomainContractClaims(db.DB); err != nil {
+			return fmt.Errorf("failed to move domain proofs to the domain badge: %w", err)
+		}
+	}
+
+	if err := SeedAdminRoles(db.DB, cfg); err != nil {
+		return fmt.Errorf("failed to seed admin roles: %w", err)
+	}
+
+	// The audit log is append-only
+	for _, stmt := range auditTriggers {
+		if err := db.Exec(stmt).Error; err != nil {
+			return fmt.Errorf("failed to protect audit log: %w", err)
+		}
+	}
+
+	// Aggregate tables created by this run are filled from existing rows
+	needsRebuild := (cfg.EnableModules.Reviews && !db.Migrator().HasTable(&AppRatingStat{})) ||
+		(cfg.EnableModules.Poe && !db.Migrator().HasTable(&UserPointTotal{}))
+
 	// Migrate plugin-specific tables based on enabled modules
 	if cfg.EnableModules.Reviews {
-		if err := db.AutoMigrate(&Review{}); err != nil {
+		if err := db.AutoMigrate(&Review{}, &ReviewRevision{}, &ReviewReply{}, &ReviewVote{}, &AppRatingStat{}); err != nil {
 			return fmt.Errorf("failed to migrate reviews table: %w", err)
 		}
+		// Reviews written before reviewer addresses were normalized
+		changed, err := normalizeAddresses(db.DB, &Review{}, "user_address")
+		if err != nil {
+			return err
+		}
+		if changed > 0 {
+			log.Printf("Normalized the reviewer address of %d reviews", changed)
+		}
 	}
 
 	if cfg.EnableModules.Poe {
-		if err := db.AutoMigrate(&Point{}); err != nil {
+		if err := db.AutoMigrate(&UserPointTotal{}, &AppUserPointTotal{}, &Point{}, &EngagementNonce{}, &Season{}, &SeasonStanding{}, &RewardDistribution{}, &RewardAllocation{}); err != nil {
 			return fmt.Errorf("failed to migrate points table: %w", err)
 		}
+		// A transaction can back at most one engagement
+		if err := uniquePointTxHashes(db); err != nil {
+			return fmt.Errorf("failed to index points tx hashes: %w", err)
+		}
+		// Points recorded before addresses were normalized split a user's
+		// totals across spellings of the same address
+		if !needsRebuild {
+			changed, err := NormalizePointAddresses(db.DB)
+			if err != nil {
+				return err
+			}
+			if changed > 0 {
+				log.Printf("Normalized the user address of %d points", changed)
+				needsRebuild = true
+			}
+		}
 	}
 
 	if cfg.EnableModules.Boosting {
@@ -57,6 +121,68 @@ func RunMigrations(db *DB, cfg *config.Config) error {
 		}
 	}
 
+	if needsRebuild {
+		log.Println("Building aggregate tables...")
+		if err := RebuildAggregates(db, cfg); err != nil {
+			return err
+		}
+	}
+
 	log.Println("Database migrations completed successfully")
 	return nil
 }
+
+// uniquePointTxHashes stores points tx hashes lowercased and indexes them
+// as unique. Engagements recorded before the index existed may share a
+// transaction; the earliest keeps it and the others keep their points but
+// lose the hash.
+func uniquePointTxHashes(db *DB) error {
+	if db.Migrator().HasIndex(&Point{}, "idx_points_tx_hash_lower") {
+		return nil
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DROP INDEX IF EXISTS idx_points_tx_hash").Error; err != nil {
+			return err
+		}
+		result := tx.Exec(`UPDATE points SET tx_hash = '' WHERE tx_hash <> '' AND id NOT IN
+			(SELECT MIN(id) FROM points WHERE tx_hash <> '' GROUP BY LOWER(tx_hash))`)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("Released %d duplicate points tx hashes", result.RowsAffected)
+		}
+		if err := tx.Exec("UPDATE points SET tx_hash = LOWER(tx_hash) WHERE tx_hash <> LOWER(tx_hash)").Error; err != nil {
+			return err
+		}
+		return tx.Exec("CREATE UNIQUE INDEX idx_points_tx_hash_lower ON points(LOWER(tx_hash)) WHERE tx_hash <> ''").Error
+	})
+}
+
+// normalizeAddresses rewrites the addresses in a column of model's table to
+// their checksummed form and returns the number of rows changed
+func normalizeAddresses(tx *gorm.DB, model interface{}, column string) (int64, error) {
+	var addresses []string
+	if err := tx.Model(model).Unscoped().Distinct(column).Pluck(column, &addresses).Error; err != nil {
+		return 0, fmt.Errorf("failed to list %s values: %w", column, err)
+	}
+
+	var changed int64
+	for _, address := range addresses {
+		normalized := utils.NormalizeAddress(address)
+		if normalized == address {
+			continue
+		}
+		result := tx.Model(model).Unscoped().Where(column+" = ?", address).UpdateColumn(column, normalized)
+		if result.Error != nil {
+			return 0, fmt.Errorf("failed to normalize %s %s: %w", column, address, result.Error)
+		}
+		changed += result.RowsAffected
+	}
+	return changed, nil
+}
+
+// IsUniqueViolation reports whether err was caused by a unique constraint
+func IsUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
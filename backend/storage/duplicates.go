@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+
+	"github.com/blockvantage/chain-app-store/backend/config"
+	"github.com/blockvantage/chain-app-store/backend/storage/filestore"
+	"github.com/blockvantage/chain-app-store/backend/storage/linkcheck"
+)
+
+// duplicatesReporter is the reporter address of flags raised for listings
+// that resemble existing apps
+const duplicatesReporter = "system:duplicates"
+
+// maxLogoHashDistance is the number of differing hash bits under which two
+// logos are considered the same image
+const maxLogoHashDistance = 10
+
+// Signals that a listing duplicates an existing app
+const (
+	DuplicateName     = "name"
+	DuplicateContract = "contract"
+	DuplicateDomain   = "domain"
+	DuplicateLogo     = "logo"
+)
+
+// DuplicateMatch is an existing app that a new listing resembles
+type DuplicateMatch struct {
+	AppID            uint     `json:"appId"`
+	Name             string   `json:"name"`
+	DeveloperAddress string   `json:"developerAddress"`
+	Verified         bool     `json:"verified"`
+	Signals          []string `json:"signals"`
+}
+
+// genericNameWords are words that do not tell app names apart, so "Uniswap
+// Protocol" and "Uniswap App" both match "Uniswap"
+var genericNameWords = map[string]bool{
+	"the": true, "app": true, "apps": true, "protocol": true, "finance": true,
+	"labs": true, "network": true, "official": true, "dao": true, "io": true,
+}
+
+// normalizeAppName reduces an app name to a comparable form: lowercase
+// words without punctuation, generic words or lookalike characters
+func normalizeAppName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var kept []string
+	for _, word := range words {
+		if !genericNameWords[word] {
+			kept = append(kept, word)
+		}
+	}
+	if len(kept) == 0 {
+		kept = words
+	}
+	return linkcheck.Skeleton(strings.Join(kept, ""))
+}
+
+// sameName reports whether two normalized names are equal or, for longer
+// names, one typo apart
+func sameName(a, b string) bool {
+	if len([]rune(a)) < 3 || len([]rune(b)) < 3 {
+		return false
+	}
+	if a == b {
+		return true
+	}
+	return len([]rune(b)) >= 6 && linkcheck.EditDistance(a, b) <= 1
+}
+
+// bareDomain returns the website host of app without a leading www.
+func bareDomain(app App) string {
+	return strings.TrimPrefix(websiteDomain(app), "www.")
+}
+
+// FindDuplicates returns the existing apps that app resembles by name,
+// contract addresses, website domain or logo
+func FindDuplicates(db *gorm.DB, app App) ([]DuplicateMatch, error) {
+	var others []App
+	err := db.Select("id", "name", "developer_address", "website_url", "logo_hash", "verified").
+		Where("id <> ?", app.ID).Find(&others).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sharedContracts := map[uint]bool{}
+	if len(app.ContractAddresses) > 0 {
+		var ids []uint
+		err := db.Model(&AppContract{}).Where("address IN ? AND app_id <> ?", app.ContractAddresses, app.ID).
+			Distinct().Pluck("app_id", &ids).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			sharedContracts[id] = true
+		}
+	}
+
+	name := normalizeAppName(app.Name)
+	domain := bareDomain(app)
+
+	var matches []DuplicateMatch
+	for _, other := range others {
+		var signals []string
+		if sameName(name, normalizeAppName(other.Name)) {
+			signals = append(signals, DuplicateName)
+		}
+		if sharedContracts[other.ID] {
+			signals = append(signals, DuplicateContract)
+		}
+		if domain != "" && domain == bareDomain(other) {
+			signals = append(signals, DuplicateDomain)
+		}
+		if app.LogoHash != "" && other.LogoHash != "" {
+			if d := filestore.HashDistance(app.LogoHash, other.LogoHash); d >= 0 && d <= maxLogoHashDistance {
+				signals = append(signals, DuplicateLogo)
+			}
+		}
+		if len(signals) > 0 {
+			matches = append(matches, DuplicateMatch{
+				AppID:            other.ID,
+				Name:             other.Name,
+				DeveloperAddress: other.DeveloperAddress,
+				Verified:         other.Verified,
+				Signals:          signals,
+			})
+		}
+	}
+	return matches, nil
+}
+
+// Impersonations returns the matches that are verified apps of another
+// developer. Listings resembling them are rejected outright rather than
+// reviewed.
+func Impersonations(app App, matches []DuplicateMatch) []DuplicateMatch {
+	var impersonated []DuplicateMatch
+	for _, match := range matches {
+		if match.Verified && !strings.EqualFold(match.DeveloperAddress, app.DeveloperAddress) {
+			impersonated = append(impersonated, match)
+		}
+	}
+	return impersonated
+}
+
+// FlagDuplicateApp raises a flag on a new app that resembles existing apps,
+// listing the conflicting app IDs for the moderator who reviews it
+func FlagDuplicateApp(db *gorm.DB, app App, matches []DuplicateMatch) error {
+	reason := "spam"
+	ids := make([]uint, len(matches))
+	details := make([]string, len(matches))
+	for i, match := range matches {
+		ids[i] = match.AppID
+		details[i] = fmt.Sprintf("App %d (%s) matches on %s", match.AppID, match.Name, strings.Join(match.Signals, ", "))
+		if !strings.EqualFold(match.DeveloperAddress, app.DeveloperAddress) {
+			reason = "impersonation"
+		}
+	}
+
+	return db.Create(&Flag{
+		Type:            FlagTypeApp,
+		ContentID:       app.ID,
+		ReporterAddress: duplicatesReporter,
+		Reason:          reason,
+		Details:         strings.Join(details, "\n"),
+		RelatedIDs:      ids,
+	}).Error
+}
+
+// HashAppLogos computes the missing logo hashes of existing apps and
+// returns how many were added. Logos that cannot be read or decoded are
+// skipped.
+func HashAppLogos(db *DB, cfg *config.Config) (int, error) {
+	fs := filestore.New(cfg)
+
+	var apps []App
+	if err := db.Select("id", "logo_path").Where("(logo_hash IS NULL OR logo_hash = '') AND logo_path <> ''").Find(&apps).Error; err != nil {
+		return 0, err
+	}
+
+	hashed := 0
+	for _, app := range apps {
+		f, err := os.Open(fs.GetImagePath(app.LogoPath))
+		if err != nil {
+			log.Printf("skipping logo of app %d: %v", app.ID, err)
+			continue
+		}
+		hash, err := filestore.PerceptualHash(f, cfg.Storage.ImagePixelLimit())
+		f.Close()
+		if err != nil {
+			log.Printf("skipping logo of app %d: %v", app.ID, err)
+			continue
+		}
+		if err := db.Model(&App{}).Where("id = ?", app.ID).UpdateColumn("logo_hash", hash).Error; err != nil {
+			return hashed, err
+		}
+		hashed++
+	}
+	return hashed, nil
+}
@@ -1,13 +1,13 @@
 package filestore
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"os"
 	"path/filepath"
-	"strings"
-	"time"
 
 	"github.com/blockvantage/chain-app-store/backend/config"
 )
@@ -22,8 +22,35 @@ func New(cfg *config.Config) *FileStore {
 	}
 }
 
-// SaveImage saves an image file to disk and returns its path
+// SaveImage validates an uploaded image, re-encodes it without metadata
+// and saves it under a generated name. It returns the path relative to the
+// images directory. Rejected uploads give an *ImageError.
 func (fs *FileStore) SaveImage(file *multipart.FileHeader, subdir string) (string, error) {
+	limit := fs.config.Storage.ImageByteLimit()
+	if file.Size > limit {
+		return "", imageErrorf("image is %s, larger than the %s limit", formatBytes(file.Size), formatBytes(limit))
+	}
+
+	// Open the uploaded file
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open uploaded file: %w", err)
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, limit+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read uploaded file: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return "", imageErrorf("image is larger than the %s limit", formatBytes(limit))
+	}
+
+	data, ext, err := sanitizeImage(data, fs.config.Storage.ImagePixelLimit())
+	if err != nil {
+		return "", err
+	}
+
 	// Create the images directory if it doesn't exist
 	imagesPath := fs.config.Storage.ImagesPath
 	if err := os.MkdirAll(imagesPath, 0755); err != nil {
@@ -38,34 +65,39 @@ func (fs *FileStore) SaveImage(file *multipart.FileHeader, subdir string) (strin
 		}
 	}
 
-	// Generate a unique filename
-	ext := filepath.Ext(file.Filename)
-	filename := fmt.Sprintf("%d_%s%s", time.Now().UnixNano(), strings.TrimSuffix(filepath.Base(file.Filename), ext), ext)
-	fullPath := filepath.Join(imagesPath, filename)
-
-	// Open the uploaded file
-	src, err := file.Open()
-	if err != nil {
-		return "", fmt.Errorf("failed to open uploaded file: %w", err)
+	// Name the file from random bytes, ignoring the client's filename
+	name := make([]byte, 16)
+	if _, err := rand.Read(name); err != nil {
+		return "", fmt.Errorf("failed to generate filename: %w", err)
 	}
-	defer src.Close()
+	filename := hex.EncodeToString(name) + ext
 
-	// Create the destination file
-	dst, err := os.Create(fullPath)
+	dst, err := os.OpenFile(filepath.Join(imagesPath, filename), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
 	if err != nil {
 		return "", fmt.Errorf("failed to create destination file: %w", err)
 	}
-	defer dst.Close()
-
-	// Copy the file
-	if _, err = io.Copy(dst, src); err != nil {
-		return "", fmt.Errorf("failed to copy file: %w", err)
+	if _, err := dst.Write(data); err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(dst.Name())
+		return "", fmt.Errorf("failed to write file: %w", err)
 	}
 
 	// Return the relative path from the base images directory
 	return filepath.Join(subdir, filename), nil
 }
 
+// formatBytes renders a byte count for error messages
+func formatBytes(n int64) string {
+	if n < 1<<20 {
+		return fmt.Sprintf("%d KiB", (n+1023)>>10)
+	}
+	return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+}
+
 // GetImagePath returns the full path to an image
 func (fs *FileStore) GetImagePath(relativePath string) string {
 	return filepath.Join(fs.config.Storage.ImagesPath, relativePath)
@@ -0,0 +1,78 @@
+package filestore
+
+import (
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"math/bits"
+	"strconv"
+)
+
+// PerceptualHash returns a 64-bit difference hash of an image as 16 hex
+// digits. Resized, recompressed or slightly recolored copies of an image
+// hash to values a few bits apart. Images of more than maxPixels pixels are
+// refused without being decoded.
+func PerceptualHash(r io.ReadSeeker, maxPixels int) (string, error) {
+	img, err := decodeWithin(r, maxPixels)
+	if err != nil {
+		return "", err
+	}
+	return differenceHash(img), nil
+}
+
+// differenceHash shrinks img to 9x8 grayscale cells and sets one bit per
+// pair of horizontally adjacent cells, for whether brightness increases
+func differenceHash(img image.Image) string {
+	const w, h = 9, 8
+	var cells [h][w]float64
+	b := img.Bounds()
+	for y := 0; y < h; y++ {
+		y0 := b.Min.Y + y*b.Dy()/h
+		y1 := max(b.Min.Y+(y+1)*b.Dy()/h, y0+1)
+		for x := 0; x < w; x++ {
+			x0 := b.Min.X + x*b.Dx()/w
+			x1 := max(b.Min.X+(x+1)*b.Dx()/w, x0+1)
+			var sum float64
+			var n int
+			for py := y0; py < y1 && py < b.Max.Y; py++ {
+				for px := x0; px < x1 && px < b.Max.X; px++ {
+					r, g, bl, a := img.At(px, py).RGBA()
+					// Colors are premultiplied by alpha, so adding the missing
+					// alpha composites transparent pixels onto white
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl) + float64(0xffff-a)
+					n++
+				}
+			}
+			if n > 0 {
+				cells[y][x] = sum / float64(n)
+			}
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w-1; x++ {
+			hash <<= 1
+			if cells[y][x+1] > cells[y][x] {
+				hash |= 1
+			}
+		}
+	}
+	return fmt.Sprintf("%016x", hash)
+}
+
+// HashDistance returns the number of differing bits between two hashes
+// from PerceptualHash, or -1 if either is malformed or carries no
+// information. Solid colors and plain left-to-right gradients hash to all
+// zeros or all ones and would match each other.
+func HashDistance(a, b string) int {
+	x, errA := strconv.ParseUint(a, 16, 64)
+	y, errB := strconv.ParseUint(b, 16, 64)
+	if errA != nil || errB != nil || x == 0 || y == 0 || x == ^uint64(0) || y == ^uint64(0) {
+		return -1
+	}
+	return bits.OnesCount64(x ^ y)
+}
@@ -0,0 +1,116 @@
+package filestore
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	_ "golang.org/x/image/webp"
+)
+
+// AcceptedImageTypes lists the upload formats SaveImage accepts
+const AcceptedImageTypes = "PNG, JPEG, WebP or GIF"
+
+// ImageError describes why an uploaded image was rejected
+type ImageError struct {
+	Reason string
+}
+
+func (e *ImageError) Error() string {
+	return e.Reason
+}
+
+func imageErrorf(format string, args ...interface{}) *ImageError {
+	return &ImageError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// sniffFormat identifies an image by its leading bytes rather than by the
+// client's filename or Content-Type
+func sniffFormat(data []byte) (string, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "png", nil
+	case bytes.HasPrefix(data, []byte("\xff\xd8\xff")):
+		return "jpeg", nil
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "gif", nil
+	case len(data) >= 12 && bytes.Equal(data[:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return "webp", nil
+	case isSVG(data):
+		return "", imageErrorf("SVG images are not accepted because they can embed scripts; upload a %s image", AcceptedImageTypes)
+	case len(data) == 0:
+		return "", imageErrorf("image file is empty")
+	}
+	return "", imageErrorf("unsupported image type %s; upload a %s image", http.DetectContentType(data), AcceptedImageTypes)
+}
+
+// isSVG reports whether data looks like XML carrying an <svg> element
+func isSVG(data []byte) bool {
+	head := data[:min(len(data), 1024)]
+	head = bytes.TrimPrefix(head, []byte("\xef\xbb\xbf"))
+	head = bytes.TrimSpace(head)
+	return bytes.HasPrefix(head, []byte("<")) && bytes.Contains(bytes.ToLower(head), []byte("<svg"))
+}
+
+// decodeWithin decodes an image after reading its dimensions, refusing
+// images of more than maxPixels pixels before their bitmap is allocated
+func decodeWithin(r io.ReadSeeker, maxPixels int) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, fmt.Errorf("image has no pixels")
+	}
+	if cfg.Width > maxPixels/cfg.Height {
+		return nil, fmt.Errorf("image is %dx%d pixels, larger than the limit of %d pixels in total", cfg.Width, cfg.Height, maxPixels)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
+
+// sanitizeImage checks that data is a supported image within maxPixels and
+// re-encodes it from its decoded pixels, which drops EXIF and other
+// metadata along with anything appended to the file. WebP is stored as
+// PNG, and only the first frame of an animated GIF is kept. It returns the
+// new file contents and their extension.
+func sanitizeImage(data []byte, maxPixels int) ([]byte, string, error) {
+	format, err := sniffFormat(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// A small file must not expand into a huge bitmap
+	img, err := decodeWithin(bytes.NewReader(data), maxPixels)
+	if err != nil {
+		return nil, "", &ImageError{Reason: err.Error()}
+	}
+
+	var out bytes.Buffer
+	ext := ".png"
+	switch format {
+	case "jpeg":
+		ext = ".jpg"
+		err = jpeg.Encode(&out, img, &jpeg.Options{Quality: 90})
+	case "gif":
+		ext = ".gif"
+		err = gif.Encode(&out, img, nil)
+	default:
+		err = png.Encode(&out, img)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to re-encode image: %w", err)
+	}
+	return out.Bytes(), ext, nil
+}
@@ -0,0 +1,132 @@
+package filestore
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func testImage(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	return img
+}
+
+func encoded(t *testing.T, encode func(*bytes.Buffer, image.Image) error, img image.Image) []byte {
+	var buf bytes.Buffer
+	if err := encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodePNG(buf *bytes.Buffer, img image.Image) error  { return png.Encode(buf, img) }
+func encodeGIF(buf *bytes.Buffer, img image.Image) error  { return gif.Encode(buf, img, nil) }
+func encodeJPEG(buf *bytes.Buffer, img image.Image) error { return jpeg.Encode(buf, img, nil) }
+
+func TestSniffFormat(t *testing.T) {
+	img := testImage(4, 4)
+	for _, tc := range []struct {
+		name   string
+		data   []byte
+		format string
+		reason string
+	}{
+		{"png", encoded(t, encodePNG, img), "png", ""},
+		{"jpeg", encoded(t, encodeJPEG, img), "jpeg", ""},
+		{"gif", encoded(t, encodeGIF, img), "gif", ""},
+		{"webp", []byte("RIFF\x24\x00\x00\x00WEBPVP8 "), "webp", ""},
+		{"svg", []byte("\xef\xbb\xbf  <?xml version=\"1.0\"?>\n<SVG xmlns=\"http://www.w3.org/2000/svg\"><script/></SVG>"), "", "SVG images are not accepted"},
+		{"empty", nil, "", "image file is empty"},
+		{"html", []byte("<html><body>hi</body></html>"), "", "unsupported image type text/html"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			format, err := sniffFormat(tc.data)
+			if tc.reason == "" {
+				if err != nil || format != tc.format {
+					t.Fatalf("sniffFormat = %q, %v; want %q", format, err, tc.format)
+				}
+				return
+			}
+			var imageErr *ImageError
+			if !errors.As(err, &imageErr) || !strings.Contains(imageErr.Reason, tc.reason) {
+				t.Fatalf("sniffFormat error = %v, want an ImageError containing %q", err, tc.reason)
+			}
+		})
+	}
+}
+
+func TestSanitizeImageReencodes(t *testing.T) {
+	img := testImage(16, 8)
+
+	// An EXIF segment after the JPEG start marker and bytes after its end
+	plain := encoded(t, encodeJPEG, img)
+	exif := []byte("\xff\xe1\x00\x10Exif\x00\x00secret!!")
+	withExif := append(append(append([]byte{}, plain[:2]...), exif...), plain[2:]...)
+	withExif = append(withExif, []byte("<?php payload")...)
+
+	for _, tc := range []struct {
+		name string
+		data []byte
+		ext  string
+	}{
+		{"jpeg", withExif, ".jpg"},
+		{"png", append(encoded(t, encodePNG, img), "trailer"...), ".png"},
+		{"gif", encoded(t, encodeGIF, img), ".gif"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, ext, err := sanitizeImage(tc.data, 1<<20)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ext != tc.ext {
+				t.Errorf("ext = %s, want %s", ext, tc.ext)
+			}
+			for _, leaked := range []string{"Exif", "secret", "payload", "trailer"} {
+				if bytes.Contains(out, []byte(leaked)) {
+					t.Errorf("output still contains %q", leaked)
+				}
+			}
+			cfg, _, err := image.DecodeConfig(bytes.NewReader(out))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Width != 16 || cfg.Height != 8 {
+				t.Errorf("output is %dx%d, want 16x8", cfg.Width, cfg.Height)
+			}
+		})
+	}
+}
+
+func TestSanitizeImageRejects(t *testing.T) {
+	valid := encoded(t, encodePNG, testImage(16, 8))
+	for _, tc := range []struct {
+		name      string
+		data      []byte
+		maxPixels int
+		reason    string
+	}{
+		{"too many pixels", valid, 100, "16x8 pixels, larger than the limit of 100"},
+		{"truncated", valid[:len(valid)/2], 1 << 20, "failed to decode image"},
+		{"header only", valid[:8], 1 << 20, "failed to decode image"},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), 1 << 20, "SVG"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := sanitizeImage(tc.data, tc.maxPixels)
+			var imageErr *ImageError
+			if !errors.As(err, &imageErr) || !strings.Contains(imageErr.Reason, tc.reason) {
+				t.Fatalf("sanitizeImage error = %v, want an ImageError containing %q", err, tc.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package filestore
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// Variant is a resized copy of an image that fits in a Size x Size square
+type Variant struct {
+	Name string
+	Size int
+}
+
+// LogoVariants are the sizes generated for app logos
+var LogoVariants = []Variant{{"64", 64}, {"128", 128}, {"256", 256}}
+
+// MockupVariants are the sizes generated for mockup screenshots
+var MockupVariants = []Variant{{"thumb", 320}, {"medium", 960}}
+
+// CreateVariants writes resized copies of a stored image next to it and
+// returns their paths keyed by variant name. Images already within a
+// variant's size are not enlarged, and that variant points at the
+// original. JPEG images get JPEG variants and all others PNG. Images over
+// the configured pixel limit are refused without being decoded.
+func (fs *FileStore) CreateVariants(relativePath string, variants []Variant) (map[string]string, error) {
+	f, err := os.Open(fs.GetImagePath(relativePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image: %w", err)
+	}
+	img, err := decodeWithin(f, fs.config.Storage.ImagePixelLimit())
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	ext := filepath.Ext(relativePath)
+	base := strings.TrimSuffix(relativePath, ext)
+	if ext != ".jpg" {
+		ext = ".png"
+	}
+
+	paths := make(map[string]string, len(variants))
+	var written []string
+	for _, variant := range variants {
+		bounds := img.Bounds()
+		w, h := fitWithin(bounds.Dx(), bounds.Dy(), variant.Size)
+		if w == bounds.Dx() && h == bounds.Dy() {
+			paths[variant.Name] = relativePath
+			continue
+		}
+
+		resized := image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.CatmullRom.Scale(resized, resized.Bounds(), img, bounds, draw.Src, nil)
+
+		path := base + "_" + variant.Name + ext
+		if err := fs.writeImage(path, resized); err != nil {
+			for _, p := range written {
+				_ = fs.DeleteImage(p)
+			}
+			return nil, err
+		}
+		written = append(written, path)
+		paths[variant.Name] = path
+	}
+	return paths, nil
+}
+
+// fitWithin scales w x h down to fit in a size x size square, keeping the
+// aspect ratio
+func fitWithin(w, h, size int) (int, int) {
+	if w <= size && h <= size {
+		return w, h
+	}
+	if w >= h {
+		return size, max(1, h*size/w)
+	}
+	return max(1, w*size/h), size
+}
+
+// writeImage encodes img at relativePath in the format of its extension
+func (fs *FileStore) writeImage(relativePath string, img image.Image) error {
+	dst, err := os.Create(fs.GetImagePath(relativePath))
+	if err != nil {
+		return fmt.Errorf("failed to create variant file: %w", err)
+	}
+	if filepath.Ext(relativePath) == ".jpg" {
+		err = jpeg.Encode(dst, img, &jpeg.Options{Quality: 85})
+	} else {
+		err = png.Encode(dst, img)
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(dst.Name())
+		return fmt.Errorf("failed to write variant file: %w", err)
+	}
+	return nil
+}
+
+// DeleteVariants deletes the variant files in paths, leaving the original
+// image that small images' variants point at
+func (fs *FileStore) DeleteVariants(original string, paths map[string]string) {
+	for _, path := range paths {
+		if path != original {
+			_ = fs.DeleteImage(path)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/blockvantage/chain-app-store/backend/config"
+	"github.com/blockvantage/chain-app-store/backend/utils"
+)
+
+// Content types that can be flagged
+const (
+	FlagTypeApp    = "app"
+	FlagTypeReview = "review"
+	FlagTypeReply  = "reply"
+)
+
+// FlagReasons are the accepted report reason codes
+var FlagReasons = []string{"spam", "scam", "offensive", "impersonation", "illegal", "other"}
+
+// errUnknownContent is returned when flagged content does not exist
+var errUnknownContent = errors.New("content not found")
+
+// flagMessage builds the text a reporter signs to flag content
+func flagMessage(contentType string, contentID uint, reason, details string) string {
+	message := fmt.Sprintf("Flag %s %d as %s", contentType, contentID, reason)
+	if details != "" {
+		message += "\nDetails:\n" + details
+	}
+	return message
+}
+
+// isFlagReason reports whether reason is an accepted reason code
+func isFlagReason(reason string) bool {
+	for _, r := range FlagReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// contentModel returns the model of a flaggable content type, or nil if the
+// type is unknown or its module is disabled
+func contentModel(db *gorm.DB, contentType string) interface{} {
+	var model interface{}
+	switch contentType {
+	case FlagTypeApp:
+		return &App{}
+	case FlagTypeReview:
+		model = &Review{}
+	case FlagTypeReply:
+		model = &ReviewReply{}
+	default:
+		return nil
+	}
+	if !db.Migrator().HasTable(model) {
+		return nil
+	}
+	return model
+}
+
+// SetContentHidden hides or unhides flagged content. Hiding a review also
+// removes it from the app's rating stats.
+func SetContentHidden(tx *gorm.DB, contentType string, contentID uint, hidden bool) error {
+	switch contentType {
+	case FlagTypeReview:
+		var review Review
+		if err := tx.First(&review, contentID).Error; err != nil {
+			return errUnknownContent
+		}
+		before := review
+		if err := tx.Model(&review).Update("hidden", hidden).Error; err != nil {
+			return err
+		}
+		review.Hidden = hidden
+		return ApplyReviewChange(tx, &before, &review)
+	case FlagTypeApp, FlagTypeReply:
+		result := tx.Model(contentModel(tx, contentType)).Where("id = ?", contentID).Update("hidden", hidden)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errUnknownContent
+		}
+		return nil
+	}
+	return errUnknownContent
+}
+
+// CreateFlag records a signed report of an app, review or review reply.
+// Repeat reports of the same content by the same address return the
+// existing flag. Content reaching the configured number of independent open
+// reports is hidden until an admin reviews it.
+func CreateFlag(db *DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Type            string `json:"type" binding:"required"`
+			ContentID       uint   `json:"contentId" binding:"required"`
+			ReporterAddress string `json:"reporterAddress" binding:"required"`
+			Reason          string `json:"reason" binding:"required"`
+			Details         string `json:"details" binding:"max=1000"`
+			Signature       string `json:"signature" binding:"required"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if !isFlagReason(req.Reason) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown reason", "reasons": FlagReasons})
+			return
+		}
+
+		// Verify the signature covers the full report
+		valid, err := utils.VerifySignature(req.ReporterAddress, req.Signature, flagMessage(req.Type, req.ContentID, req.Reason, req.Details))
+		if err != nil || !valid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+			return
+		}
+
+		// Check the content exists
+		model := contentModel(db.DB, req.Type)
+		if model == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "type must be app, review or reply"})
+			return
+		}
+		if err := db.First(model, req.ContentID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": req.Type + " not found"})
+			return
+		}
+
+		reporter := utils.NormalizeAddress(req.ReporterAddress)
+
+		// Deduplicate repeat reports from the same address
+		var existing Flag
+		if err := db.Where("type = ? AND content_id = ? AND reporter_address = ?", req.Type, req.ContentID, reporter).
+			Limit(1).Find(&existing).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if existing.ID != 0 {
+			c.JSON(http.StatusOK, gin.H{"flag": existing, "duplicate": true})
+			return
+		}
+
+		flag := Flag{
+			Type:            req.Type,
+			ContentID:       req.ContentID,
+			ReporterAddress: reporter,
+			Reason:          req.Reason,
+			Details:         req.Details,
+			Signature:       req.Signature,
+		}
+
+		hidden := false
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&flag).Error; err != nil {
+				return err
+			}
+
+			threshold := cfg.Moderation.AutoHideThreshold()
+			if threshold == 0 {
+				return nil
+			}
+
+			var reports int64
+			if err := tx.Model(&Flag{}).
+				Where("type = ? AND content_id = ? AND resolved = ?", flag.Type, flag.ContentID, false).
+				Count(&reports).Error; err != nil {
+				return err
+			}
+			if reports < int64(threshold) {
+				return nil
+			}
+
+			hidden = true
+			return SetContentHidden(tx, flag.Type, flag.ContentID, true)
+		})
+		if IsUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "content already reported by this address"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create flag"})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"flag": flag, "hidden": hidden})
+	}
+}
+
+// GetFlags returns a page of flags, filtered by status (open, resolved,
+// dismissed or all; default open), type and contentId (admin only)
+func GetFlags(db *DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := db.Model(&Flag{})
+
+		switch c.DefaultQuery("status", "open") {
+		case "open":
+			query = query.Where("resolved = ?", false)
+		case "resolved":
+			query = query.Where("resolved = ? AND dismissed = ?", true, false)
+		case "dismissed":
+			query = query.Where("dismissed = ?", true)
+		case "all":
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be open, resolved, dismissed or all"})
+			return
+		}
+
+		if contentType := c.Query("type"); contentType != "" {
+			query = query.Where("type = ?", contentType)
+		}
+		if reporter := c.Query("reporter"); reporter != "" {
+			query = query.Where("reporter_address = ?", reporter)
+		}
+		if contentID := c.Query("contentId"); contentID != "" {
+			id, err := strconv.ParseUint(contentID, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid content ID"})
+				return
+			}
+			query = query.Where("content_id = ?", id)
+		}
+
+		page, pageSize, offset := utils.ParsePagination(c)
+
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var flags []Flag
+		if err := query.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&flags).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"flags":      flags,
+			"pagination": utils.PaginationMeta(total, page, pageSize),
+		})
+	}
+}
+
+// ResolveFlag closes a flag and every other open flag on the same content,
+// recording the admin who did so (admin only). Resolving confirms the report
+// and keeps the content hidden. Dismissing rejects it, and with unhide set
+// restores content that was hidden.
+func ResolveFlag(db *DB, dismiss bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Unhide bool `json:"unhide"`
+		}
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		var flag Flag
+		if err := db.First(&flag, c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "flag not found"})
+			return
+		}
+		if flag.Resolved {
+			c.JSON(http.StatusConflict, gin.H{"error": "flag is already closed"})
+			return
+		}
+
+		now := time.Now()
+		var closed int64
+		err := db.Transaction(func(tx *gorm.DB) error {
+			result := tx.Model(&Flag{}).
+				Where("type = ? AND content_id = ? AND resolved = ?", flag.Type, flag.ContentID, false).
+				Updates(map[string]interface{}{
+					"resolved":    true,
+					"dismissed":   dismiss,
+					"resolved_by": AdminAddress(c),
+					"resolved_at": now,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			closed = result.RowsAffected
+
+			action := "flag.resolve"
+			if dismiss {
+				action = "flag.dismiss"
+			}
+			audit := gin.H{"type": flag.Type, "contentId": flag.ContentID, "closed": closed, "unhide": req.Unhide}
+			if err := RecordAudit(tx, c, action, "flag", flag.ID, gin.H{"resolved": false}, audit); err != nil {
+				return err
+			}
+
+			// Resolving confirms the report, so the content stays hidden
+			if !dismiss {
+				return SetContentHidden(tx, flag.Type, flag.ContentID, true)
+			}
+			if req.Unhide {
+				return SetContentHidden(tx, flag.Type, flag.ContentID, false)
+			}
+			return nil
+		})
+		if errors.Is(err, errUnknownContent) {
+			c.JSON(http.StatusNotFound, gin.H{"error": flag.Type + " not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update flags"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true, "closed": closed})
+	}
+}
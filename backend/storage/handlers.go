@@ -1,12 +1,19 @@
 package storage
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -16,11 +23,14 @@ import (
 	"github.com/blockvantage/chain-app-store/backend/utils"
 )
 
+// appSortColumns are the app columns GetApps can sort by, besides rating
+var appSortColumns = map[string]bool{"created_at": true, "updated_at": true, "name": true}
+
 // GetApps returns all visible apps
 func GetApps(db *DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var apps []App
-		query := db.Where("hidden = ?", false)
+		query := db.Model(&App{}).Where("apps.hidden = ?", false)
 
 		// Apply filters if provided
 		if category := c.Query("category"); category != "" {
@@ -28,22 +38,65 @@ func GetApps(db *DB) gin.HandlerFunc {
 		}
 
 		if featured := c.Query("featured"); featured == "true" {
-			query = query.Where("featured = ?", true)
+			query = query.Where("apps.featured = ?", true)
 		}
 
-		// Apply sorting
+		// Rating filters and sorting read the maintained rating stats
 		sortBy := c.DefaultQuery("sort", "created_at")
-		sortOrder := c.DefaultQuery("order", "desc")
-		query = query.Order(sortBy + " " + sortOrder)
+		sortOrder := strings.ToLower(c.DefaultQuery("order", "desc"))
+		if sortBy != "rating" && !appSortColumns[sortBy] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be created_at, updated_at, name or rating"})
+			return
+		}
+		if sortOrder != "asc" && sortOrder != "desc" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "order must be asc or desc"})
+			return
+		}
+		minRating := c.Query("minRating")
+		if minRating != "" || sortBy == "rating" {
+			if !db.Migrator().HasTable(&AppRatingStat{}) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "ratings are not enabled"})
+				return
+			}
+			query = query.Joins("LEFT JOIN app_rating_stats ON app_rating_stats.app_id = apps.id")
+		}
+
+		if minRating != "" {
+			threshold, err := strconv.ParseFloat(minRating, 64)
+			if err != nil || threshold < 0 || threshold > 5 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "minRating must be a number between 0 and 5"})
+				return
+			}
+			query = query.Where("app_rating_stats.rating_count > 0 AND CAST(app_rating_stats.rating_sum AS REAL) / app_rating_stats.rating_count >= ?", threshold)
+		}
+
+		// Count the filtered apps before sorting and paging
+		query = query.Session(&gorm.Session{})
+		var total int64
+		if err := query.Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		// Apply sorting. Rating sorts by the Bayesian average so that apps
+		// with a handful of perfect reviews do not outrank well-reviewed ones.
+		query = query.Select("apps.*")
+		if sortBy == "rating" {
+			prior, err := RatingPrior(db.DB)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			query = query.Clauses(orderByBayesianAverage(prior, sortOrder != "asc"))
+		} else {
+			query = query.Order("apps." + sortBy + " " + sortOrder)
+		}
 
 		// Apply pagination
 		page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 		pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
 		offset := (page - 1) * pageSize
 
-		var total int64
-		db.Model(&App{}).Where("hidden = ?", false).Count(&total)
-
 		// Check if we should include mockup images
 		includeImages := c.Query("includeImages") == "true"
 		if includeImages {
@@ -56,24 +109,6 @@ func GetApps(db *DB) gin.HandlerFunc {
 			return
 		}
 
-		// If images are included but we don't want to send the binary data in the list view
-		// we can strip it out here and just send metadata
-		if includeImages && c.Query("imagesMetadataOnly") == "true" {
-			// Add mockup image URLs
-			for i := range apps {
-				// Handle logo path
-				if apps[i].LogoPath != "" {
-					apps[i].LogoPath = apps[i].LogoPath
-				}
-				// Handle mockup images
-				for j := range apps[i].MockupImages {
-					if apps[i].MockupImages[j].ImagePath != "" {
-						apps[i].MockupImages[j].ImagePath = apps[i].MockupImages[j].ImagePath
-					}
-				}
-			}
-		}
-
 		c.JSON(http.StatusOK, gin.H{
 			"apps": apps,
 			"pagination": gin.H{
@@ -111,41 +146,35 @@ func CreateApp(db *DB, cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
+		// Reject listings with banned contracts or links. Listings are not
+		// signed, so the developer address is only what the client claims:
+		// this stops a banned developer naming their own wallet, but it does
+		// not prove who submitted the listing
+		if !EnforceBans(c, db, AppBanTargets(app)) {
+			return
+		}
+
+		// Reject malformed, misplaced or known phishing links
+		if !EnforceLinkRules(c, cfg, app) {
+			return
+		}
+
+		// Checksum the contract addresses and make sure they are deployed
+		if !EnforceContractRules(c, cfg, &app) {
+			return
+		}
+
+		// Contracts proven by another developer cannot be listed again
+		if !EnforceContractClaims(c, db, 0, app.ContractAddresses) {
+			return
+		}
+
 		// // Verify listing fee transaction if required
 		// if app.TxHash == "" {
 		// 	c.JSON(http.StatusBadRequest, gin.H{"error": "listing fee transaction hash is required"})
 		// 	return
 		// }
 
-		// In a real implementation, we would verify the transaction on the blockchain
-		// For now, we'll just save the transaction
-		tx := Transaction{
-			Hash:        app.TxHash,
-			FromAddress: app.DeveloperAddress,
-			ToAddress:   "platform_address", // This would come from config in real implementation
-			Value:       cfg.ListingFee.Amount,
-			TokenSymbol: cfg.ListingFee.Token,
-			Type:        "listing",
-			Status:      "confirmed", // In reality, we would check the status on the blockchain
-		}
-
-		// Check if transaction already exists
-		var existingTx Transaction
-		if err := db.Where("hash = ?", tx.Hash).First(&existingTx).Error; err != nil {
-			if !errors.Is(err, gorm.ErrRecordNotFound) {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check transaction"})
-				return
-			}
-			// Transaction doesn't exist, create it
-			if err := db.Create(&tx).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record transaction"})
-				return
-			}
-		} else {
-			// Transaction exists, use it
-			tx = existingTx
-		}
-
 		// Handle logo upload
 		logoFile, err := c.FormFile("logo")
 		if err != nil {
@@ -156,31 +185,59 @@ func CreateApp(db *DB, cfg *config.Config) gin.HandlerFunc {
 		// Save logo file
 		logoPath, err := fs.SaveImage(logoFile, "logos")
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save logo: " + err.Error()})
+			respondImageError(c, "logo", err)
 			return
 		}
 		app.LogoPath = logoPath
+		// Without a hash the logo is left out of the duplicate check
+		if f, err := os.Open(fs.GetImagePath(logoPath)); err != nil {
+			log.Printf("failed to hash logo %s: %v", logoPath, err)
+		} else {
+			if app.LogoHash, err = filestore.PerceptualHash(f, cfg.Storage.ImagePixelLimit()); err != nil {
+				log.Printf("failed to hash logo %s: %v", logoPath, err)
+			}
+			f.Close()
+		}
 
-		// Create the app first to get an ID
-		if err := db.Create(&app).Error; err != nil {
-			// Clean up the logo file if app creation fails
+		// Uploaded files and their variants are removed again if the
+		// listing is not created
+		var mockupImages []AppImage
+		discardImages := func() {
 			_ = fs.DeleteImage(logoPath)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create app"})
-			return
+			fs.DeleteVariants(logoPath, app.LogoVariants)
+			for _, image := range mockupImages {
+				_ = fs.DeleteImage(image.ImagePath)
+				fs.DeleteVariants(image.ImagePath, image.Variants)
+			}
 		}
 
-		// Update the transaction with the app ID
-		if err := db.Model(&tx).Update("app_id", app.ID).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update transaction"})
+		app.LogoVariants, err = fs.CreateVariants(logoPath, filestore.LogoVariants)
+		if err != nil {
+			discardImages()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to resize logo: " + err.Error()})
 			return
 		}
 
-		// Handle mockup images
-		form := c.Request.MultipartForm
-		if form != nil && form.File != nil {
-			var mockupImages []AppImage
+		// Reject listings imitating a verified app, and hold back other
+		// likely duplicates until a moderator reviews them
+		duplicates, err := FindDuplicates(db.DB, app)
+		if err != nil {
+			discardImages()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check for duplicate apps"})
+			return
+		}
+		if impersonated := Impersonations(app, duplicates); len(impersonated) > 0 {
+			discardImages()
+			c.JSON(http.StatusConflict, gin.H{"error": "listing resembles a verified app", "duplicates": impersonated})
+			return
+		}
+		if len(duplicates) > 0 {
+			app.Hidden = true
+		}
 
-			// Get all mockup image files
+		// Save mockup images before creating the app, so a rejected image
+		// leaves no partial listing behind
+		if form := c.Request.MultipartForm; form != nil && form.File != nil {
 			for key, files := range form.File {
 				if strings.HasPrefix(key, "mockups[") && len(files) > 0 {
 					// Extract index from key (e.g., "mockups[0]" -> 0)
@@ -191,7 +248,15 @@ func CreateApp(db *DB, cfg *config.Config) gin.HandlerFunc {
 						// Save the mockup image
 						imagePath, err := fs.SaveImage(fileHeader, "mockups")
 						if err != nil {
-							c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save mockup image: " + err.Error()})
+							discardImages()
+							respondImageError(c, key, err)
+							return
+						}
+						variants, err := fs.CreateVariants(imagePath, filestore.MockupVariants)
+						if err != nil {
+							_ = fs.DeleteImage(imagePath)
+							discardImages()
+							c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to resize " + key + ": " + err.Error()})
 							return
 						}
 
@@ -203,26 +268,57 @@ func CreateApp(db *DB, cfg *config.Config) gin.HandlerFunc {
 							}
 						}
 
-						// Create mockup image record
-						mockupImage := AppImage{
-							AppID:       app.ID,
+						mockupImages = append(mockupImages, AppImage{
 							Filename:    fileHeader.Filename,
 							ImagePath:   imagePath,
+							Variants:    variants,
 							Description: description,
 							Order:       index,
-						}
-
-						mockupImages = append(mockupImages, mockupImage)
+						})
 					}
 				}
 			}
+		}
 
-			// Save all mockup images in a transaction
-			if len(mockupImages) > 0 {
-				if err := db.Create(&mockupImages).Error; err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save mockup images"})
-					return
-				}
+		// Create the app first to get an ID, together with its listing fee
+		// transaction, its contract index and, for a held back duplicate,
+		// the flag that queues it for review, so a listing is never stored
+		// without them and a rejected listing records no fee
+		err = db.Transaction(func(dbTx *gorm.DB) error {
+			if err := dbTx.Create(&app).Error; err != nil {
+				return err
+			}
+			if err := recordListingFee(dbTx, cfg, app); err != nil {
+				return err
+			}
+			if err := SyncAppContracts(dbTx, app.ID, app.ContractAddresses); err != nil {
+				return err
+			}
+			if len(duplicates) > 0 {
+				return FlagDuplicateApp(dbTx, app, duplicates)
+			}
+			return nil
+		})
+		if err != nil {
+			// Clean up the image files if app creation fails
+			discardImages()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create app"})
+			return
+		}
+
+		// Queue listings whose website imitates another app for moderation
+		if _, err := FlagLookalikeApp(db.DB, app); err != nil {
+			log.Printf("lookalike check failed for app %d: %v", app.ID, err)
+		}
+
+		// Save all mockup image records
+		if len(mockupImages) > 0 {
+			for i := range mockupImages {
+				mockupImages[i].AppID = app.ID
+			}
+			if err := db.Create(&mockupImages).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save mockup images"})
+				return
 			}
 		}
 
@@ -233,8 +329,38 @@ func CreateApp(db *DB, cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusCreated, completeApp)
+		// Listings held for review are accepted but not yet visible
+		status := http.StatusCreated
+		if completeApp.Hidden {
+			status = http.StatusAccepted
+		}
+		c.JSON(status, completeApp)
+	}
+}
+
+// recordListingFee stores the listing fee transaction of a new app, or
+// links an already recorded transaction with the same hash to it
+func recordListingFee(tx *gorm.DB, cfg *config.Config, app App) error {
+	var existing Transaction
+	if err := tx.Where("hash = ?", app.TxHash).Limit(1).Find(&existing).Error; err != nil {
+		return err
+	}
+	if existing.ID != 0 {
+		return tx.Model(&existing).Update("app_id", app.ID).Error
 	}
+
+	// In a real implementation, we would verify the transaction on the blockchain
+	// For now, we'll just save the transaction
+	return tx.Create(&Transaction{
+		Hash:        app.TxHash,
+		FromAddress: app.DeveloperAddress,
+		ToAddress:   "platform_address", // This would come from config in real implementation
+		Value:       cfg.ListingFee.Amount,
+		TokenSymbol: cfg.ListingFee.Token,
+		Type:        "listing",
+		AppID:       app.ID,
+		Status:      "confirmed", // In reality, we would check the status on the blockchain
+	}).Error
 }
 
 // FeatureApp marks an app as featured
@@ -256,7 +382,14 @@ func FeatureApp(db *DB) gin.HandlerFunc {
 			return
 		}
 
-		if err := db.Model(&app).Update("featured", req.Featured).Error; err != nil {
+		before := gin.H{"featured": app.Featured}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&app).Update("featured", req.Featured).Error; err != nil {
+				return err
+			}
+			return RecordAudit(tx, c, "app.feature", FlagTypeApp, app.ID, before, gin.H{"featured": req.Featured})
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update app"})
 			return
 		}
@@ -284,7 +417,14 @@ func HideApp(db *DB) gin.HandlerFunc {
 			return
 		}
 
-		if err := db.Model(&app).Update("hidden", req.Hidden).Error; err != nil {
+		before := gin.H{"hidden": app.Hidden}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&app).Update("hidden", req.Hidden).Error; err != nil {
+				return err
+			}
+			return RecordAudit(tx, c, "app.hide", FlagTypeApp, app.ID, before, gin.H{"hidden": req.Hidden})
+		})
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update app"})
 			return
 		}
@@ -320,6 +460,17 @@ func GetAppImage(db *DB, cfg *config.Config) gin.HandlerFunc {
 	}
 }
 
+// respondImageError answers a failed upload of the named form field, with
+// 400 for images that failed validation
+func respondImageError(c *gin.Context, field string, err error) {
+	var imageErr *filestore.ImageError
+	if errors.As(err, &imageErr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + field + ": " + imageErr.Reason, "field": field})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save " + field + ": " + err.Error()})
+}
+
 // GetApp returns a specific app by ID with its mockup images
 func GetApp(db *DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -330,7 +481,9 @@ func GetApp(db *DB) gin.HandlerFunc {
 		}
 
 		var app App
-		query := db.Model(&App{}).Preload("MockupImages")
+		query := db.Model(&App{}).Preload("MockupImages").Preload("LinkHealth", func(db *gorm.DB) *gorm.DB {
+			return db.Order("field")
+		})
 
 		if err := query.First(&app, appID).Error; err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "app not found"})
@@ -341,30 +494,113 @@ func GetApp(db *DB) gin.HandlerFunc {
 	}
 }
 
-// AdminAuthMiddleware verifies that the request is from an admin
-func AdminAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+// adminRequestWindow is how far the X-Admin-Timestamp of an admin request may
+// be from the server clock
+const adminRequestWindow = 5 * time.Minute
+
+// maxAdminBodyBytes bounds the admin request body read for the signature
+const maxAdminBodyBytes = 8 << 20
+
+// adminRequestMessage builds the text an admin signs for one request. It
+// binds the signature to the method, path with query, body, timestamp and
+// nonce, so it cannot be reused for another request.
+func adminRequestMessage(method, path string, body []byte, timestamp int64, nonce string) string {
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf("Admin request\nMethod: %s\nPath: %s\nBody: %s\nTimestamp: %d\nNonce: %s",
+		method, path, hex.EncodeToString(sum[:]), timestamp, nonce)
+}
+
+// AdminAuthMiddleware verifies that the request is signed by an admin whose
+// role grants the given permission. An empty permission admits any admin.
+// Each request carries its own timestamp and nonce, and a nonce is accepted
+// only once.
+func AdminAuthMiddleware(db *DB, permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get signature from header
 		signature := c.GetHeader("X-Admin-Signature")
-		if signature == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "admin signature required"})
+		nonce := c.GetHeader("X-Admin-Nonce")
+		timestamp, err := strconv.ParseInt(c.GetHeader("X-Admin-Timestamp"), 10, 64)
+		if signature == "" || nonce == "" || err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "admin signature, timestamp and nonce required"})
+			return
+		}
+		if len(nonce) > 64 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "admin request nonce too long"})
+			return
+		}
+		now := time.Now()
+		signedAt := time.Unix(timestamp, 0)
+		if signedAt.Before(now.Add(-adminRequestWindow)) || signedAt.After(now.Add(adminRequestWindow)) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "admin request timestamp out of range"})
+			return
+		}
+
+		// Read the body for its hash and put it back for the handler
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxAdminBodyBytes+1))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if len(body) > maxAdminBodyBytes {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "admin request body too large"})
 			return
 		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
 
 		// Get wallet address from signature
-		// In a real implementation, we would verify the signature
-		// For now, we'll just check if the address is in the admin list
-		walletAddress, err := utils.RecoverAddressFromSignature(signature, "message")
+		message := adminRequestMessage(c.Request.Method, c.Request.URL.RequestURI(), body, timestamp, nonce)
+		walletAddress, err := utils.RecoverAddressFromSignature(signature, message)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
 			return
 		}
 
-		if !cfg.IsAdminWallet(walletAddress) {
+		role, err := GetAdminRole(db.DB, walletAddress)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if role == "" {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "not an admin wallet"})
 			return
 		}
+		if permission != "" && !RoleHasPermission(role, permission) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "role " + role + " lacks permission " + permission})
+			return
+		}
+
+		// Consume the nonce. Nonces older than the timestamp window can no
+		// longer be replayed, so they are dropped.
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Unscoped().Where("created_at < ?", now.Add(-2*adminRequestWindow)).Delete(&AdminNonce{}).Error; err != nil {
+				return err
+			}
+			return tx.Create(&AdminNonce{Address: walletAddress, Nonce: nonce}).Error
+		})
+		if IsUniqueViolation(err) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "admin request nonce already used"})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 
+		c.Set(adminAddressKey, walletAddress)
+		c.Set(adminNonceKey, nonce)
+		c.Set(adminTimestampKey, timestamp)
 		c.Next()
 	}
 }
+
+// Context keys holding the authenticated admin wallet and the nonce and
+// timestamp of its signed request
+const (
+	adminAddressKey   = "adminAddress"
+	adminNonceKey     = "adminNonce"
+	adminTimestampKey = "adminTimestamp"
+)
+
+// AdminAddress returns the admin wallet authenticated by AdminAuthMiddleware
+func AdminAddress(c *gin.Context) string {
+	return c.GetString(adminAddressKey)
+}
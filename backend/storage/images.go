@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/blockvantage/chain-app-store/backend/config"
+	"github.com/blockvantage/chain-app-store/backend/storage/filestore"
+)
+
+// CreateImageVariants generates the size variants of logos and mockups
+// uploaded before variants existed and returns how many images were
+// processed. Images that cannot be read or decoded are skipped.
+func CreateImageVariants(db *DB, cfg *config.Config) (int, error) {
+	fs := filestore.New(cfg)
+	processed := 0
+
+	var apps []App
+	err := db.Select("id", "logo_path").
+		Where("(logo_variants IS NULL OR logo_variants IN ('', 'null')) AND logo_path <> ''").Find(&apps).Error
+	if err != nil {
+		return 0, err
+	}
+	for _, app := range apps {
+		variants, err := fs.CreateVariants(app.LogoPath, filestore.LogoVariants)
+		if err != nil {
+			log.Printf("skipping logo of app %d: %v", app.ID, err)
+			continue
+		}
+		data, err := json.Marshal(variants)
+		if err != nil {
+			return processed, err
+		}
+		if err := db.Model(&App{}).Where("id = ?", app.ID).UpdateColumn("logo_variants", string(data)).Error; err != nil {
+			return processed, err
+		}
+		processed++
+	}
+
+	var images []AppImage
+	err = db.Select("id", "app_id", "image_path").
+		Where("(variants IS NULL OR variants IN ('', 'null')) AND image_path <> ''").Find(&images).Error
+	if err != nil {
+		return processed, err
+	}
+	for _, image := range images {
+		variants, err := fs.CreateVariants(image.ImagePath, filestore.MockupVariants)
+		if err != nil {
+			log.Printf("skipping mockup %d of app %d: %v", image.ID, image.AppID, err)
+			continue
+		}
+		data, err := json.Marshal(variants)
+		if err != nil {
+			return processed, err
+		}
+		if err := db.Model(&AppImage{}).Where("id = ?", image.ID).UpdateColumn("variants", string(data)).Error; err != nil {
+			return processed, err
+		}
+		processed++
+	}
+	return processed, nil
+}
@@ -0,0 +1,185 @@
+package linkcheck
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Blocklist is a file of known phishing domains, one per line, with blank
+// lines and lines starting with # ignored. The file is re-read whenever it
+// changes on disk, so it can be replaced by a feed without a restart.
+type Blocklist struct {
+	path    string
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	domains map[string]bool
+}
+
+var (
+	blocklistsMu sync.Mutex
+	blocklists   = map[string]*Blocklist{}
+)
+
+// OpenBlocklist returns the shared blocklist for a file path. A missing
+// file is treated as an empty list.
+func OpenBlocklist(path string) *Blocklist {
+	blocklistsMu.Lock()
+	defer blocklistsMu.Unlock()
+	if b, ok := blocklists[path]; ok {
+		return b
+	}
+	b := &Blocklist{path: path, domains: map[string]bool{}}
+	blocklists[path] = b
+	return b
+}
+
+// Path returns the file backing the blocklist
+func (b *Blocklist) Path() string {
+	return b.path
+}
+
+// reload re-reads the file if it changed since the last read. The caller
+// must hold b.mu.
+func (b *Blocklist) reload() error {
+	info, err := os.Stat(b.path)
+	if os.IsNotExist(err) {
+		b.domains, b.modTime, b.size = map[string]bool{}, time.Time{}, 0
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(b.modTime) && info.Size() == b.size {
+		return nil
+	}
+
+	f, err := os.Open(b.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	domains := map[string]bool{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if domain := normalizeDomain(scanner.Text()); domain != "" {
+			domains[domain] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read blocklist: %w", err)
+	}
+	b.domains, b.modTime, b.size = domains, info.ModTime(), info.Size()
+	return nil
+}
+
+// normalizeDomain returns the lowercase domain of a blocklist line, or ""
+// for blank and comment lines
+func normalizeDomain(line string) string {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ""
+	}
+	line = strings.ToLower(strings.TrimPrefix(line, "*."))
+	return strings.TrimSuffix(line, ".")
+}
+
+// Match returns the blocklisted domain covering host, if any
+func (b *Blocklist) Match(host string) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.reload(); err != nil {
+		return "", err
+	}
+	for host != "" {
+		if b.domains[host] {
+			return host, nil
+		}
+		i := strings.Index(host, ".")
+		if i < 0 {
+			break
+		}
+		host = host[i+1:]
+	}
+	return "", nil
+}
+
+// Domains returns the blocklisted domains in sorted order
+func (b *Blocklist) Domains() ([]string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.reload(); err != nil {
+		return nil, err
+	}
+	domains := make([]string, 0, len(b.domains))
+	for domain := range b.domains {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	return domains, nil
+}
+
+// Update adds and removes domains and rewrites the file. Comments in the
+// existing file are kept. It returns the domains that were actually added
+// and removed.
+func (b *Blocklist) Update(add, remove []string) (added, removed []string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.reload(); err != nil {
+		return nil, nil, err
+	}
+
+	removeSet := map[string]bool{}
+	for _, domain := range remove {
+		if domain = normalizeDomain(domain); domain != "" && b.domains[domain] {
+			removeSet[domain] = true
+			removed = append(removed, domain)
+		}
+	}
+	for _, domain := range add {
+		if domain = normalizeDomain(domain); domain != "" && !b.domains[domain] && !removeSet[domain] {
+			b.domains[domain] = true
+			added = append(added, domain)
+		}
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return nil, nil, nil
+	}
+
+	var lines []string
+	if data, err := os.ReadFile(b.path); err == nil {
+		for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+			if domain := normalizeDomain(line); domain != "" && removeSet[domain] {
+				continue
+			}
+			lines = append(lines, line)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, nil, err
+	}
+	lines = append(lines, added...)
+
+	// Write to a temporary file and rename it so readers never see a
+	// partially written list
+	if err := os.MkdirAll(filepath.Dir(b.path), 0755); err != nil {
+		return nil, nil, err
+	}
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return nil, nil, err
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		return nil, nil, err
+	}
+
+	// Force a re-read so the cached set matches the file
+	b.modTime, b.size = time.Time{}, 0
+	return added, removed, b.reload()
+}
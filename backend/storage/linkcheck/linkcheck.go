@@ -0,0 +1,103 @@
+package linkcheck
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// LinkError describes why a submitted link was rejected
+type LinkError struct {
+	Field  string `json:"field"`
+	URL    string `json:"url"`
+	Reason string `json:"reason"`
+}
+
+func (e *LinkError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+}
+
+// Link is a URL submitted in a listing field
+type Link struct {
+	Field string
+	URL   string
+}
+
+// SocialHosts lists the hosts accepted for each social link field. A host
+// also admits its subdomains.
+var SocialHosts = map[string][]string{
+	"twitterUrl":  {"twitter.com", "x.com"},
+	"discordUrl":  {"discord.gg", "discord.com", "discordapp.com"},
+	"telegramUrl": {"t.me", "telegram.me"},
+	"mediumUrl":   {"medium.com"},
+	"githubUrl":   {"github.com"},
+}
+
+// Parse checks that raw is an absolute http or https URL with a public
+// host name and returns it parsed
+func Parse(raw string) (*url.URL, error) {
+	if strings.TrimSpace(raw) != raw {
+		return nil, fmt.Errorf("must not contain leading or trailing whitespace")
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL")
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return nil, fmt.Errorf("scheme must be http or https")
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("must not contain credentials")
+	}
+	host := Host(u)
+	switch {
+	case host == "":
+		return nil, fmt.Errorf("host is required")
+	case net.ParseIP(host) != nil:
+		return nil, fmt.Errorf("host must be a domain name, not an IP address")
+	case !strings.Contains(host, "."):
+		return nil, fmt.Errorf("host must be a fully qualified domain name")
+	}
+	return u, nil
+}
+
+// Host returns the lowercase host name of u without a trailing dot
+func Host(u *url.URL) string {
+	return strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+}
+
+// MatchesDomain reports whether host is domain or one of its subdomains
+func MatchesDomain(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// Validate checks the syntax of each non-empty link and that social fields
+// point at their platform. It returns the first problem found.
+func Validate(links []Link) *LinkError {
+	for _, link := range links {
+		if link.URL == "" {
+			continue
+		}
+		u, err := Parse(link.URL)
+		if err != nil {
+			return &LinkError{Field: link.Field, URL: link.URL, Reason: err.Error()}
+		}
+		hosts, social := SocialHosts[link.Field]
+		if !social {
+			continue
+		}
+		matched := false
+		for _, domain := range hosts {
+			if MatchesDomain(Host(u), domain) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return &LinkError{Field: link.Field, URL: link.URL,
+				Reason: "host must be " + strings.Join(hosts, " or ")}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package linkcheck
+
+import (
+	"strings"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// homoglyphs maps characters that render like an ASCII letter or digit to
+// a common form, so "un1swаp" (Cyrillic а) and "uniswap" compare equal
+var homoglyphs = map[rune]string{
+	'0': "o", '1': "l", 'i': "l", '3': "e", '5': "s", '@': "a",
+	'а': "a", 'е': "e", 'о': "o", 'р': "p", 'с': "c", 'у': "y", 'х': "x",
+	'і': "l", 'ј': "j", 'ѕ': "s", 'ԁ': "d", 'ɡ': "g", 'һ': "h", 'ӏ': "l",
+	'α': "a", 'ο': "o", 'ν': "v", 'ι': "l", 'κ': "k", 'τ': "t", 'ρ': "p",
+	'ı': "l", 'ł': "l", 'ǀ': "l",
+}
+
+// multiGlyphs are letter sequences that read as a single letter
+var multiGlyphs = strings.NewReplacer("rn", "m", "vv", "w", "cl", "d")
+
+// Skeleton returns the form of a domain label used for lookalike
+// comparison. Punycode labels are decoded first.
+func Skeleton(label string) string {
+	if strings.HasPrefix(label, "xn--") {
+		if decoded, err := idna.ToUnicode(label); err == nil {
+			label = decoded
+		}
+	}
+	var b strings.Builder
+	for _, r := range strings.ToLower(label) {
+		if r == '-' {
+			continue
+		}
+		if s, ok := homoglyphs[r]; ok {
+			b.WriteString(s)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return multiGlyphs.Replace(b.String())
+}
+
+// BrandLabel returns the label of host that names the site, which is the
+// one before the public suffix, e.g. "uniswap" for "app.uniswap.org" and
+// "example" for "www.example.co.uk"
+func BrandLabel(host string) string {
+	domain := registered(host)
+	if i := strings.Index(domain, "."); i >= 0 {
+		return domain[:i]
+	}
+	return domain
+}
+
+// EditDistance returns the Levenshtein distance between a and b
+func EditDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// maxDistance is the edit distance under which two brand labels of the
+// given length are considered lookalikes. Short labels are too easily close
+// to each other by chance to compare.
+func maxDistance(length int) int {
+	switch {
+	case length < 5:
+		return 0
+	case length < 9:
+		return 1
+	}
+	return 2
+}
+
+// IsLookalike reports whether host imitates known, a different host. Hosts
+// on the same registered domain are never lookalikes of each other.
+func IsLookalike(host, known string) bool {
+	label, knownLabel := BrandLabel(host), BrandLabel(known)
+	if host == known || MatchesDomain(host, registered(known)) || MatchesDomain(known, registered(host)) {
+		return false
+	}
+	if label == knownLabel {
+		// Same name under another top-level domain
+		return true
+	}
+	a, b := Skeleton(label), Skeleton(knownLabel)
+	if a == b {
+		return true
+	}
+	n := len([]rune(b))
+	if n >= 5 && strings.Contains(a, b) {
+		// The other name with words added, e.g. "uniswap-app"
+		return true
+	}
+	return EditDistance(a, b) <= maxDistance(n)
+}
+
+// registered returns the registrable domain of host, the public suffix
+// plus one label, e.g. "example.co.uk" for "app.example.co.uk"
+func registered(host string) string {
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
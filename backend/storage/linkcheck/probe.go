@@ -0,0 +1,144 @@
+package linkcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ProbeResult is the outcome of requesting a link
+type ProbeResult struct {
+	StatusCode int
+	Latency    time.Duration
+	Err        error
+}
+
+// OK reports whether the link is reachable. Servers that refuse automated
+// requests (401, 403, 429) are up, so only missing pages, server errors and
+// network failures count as broken.
+func (r ProbeResult) OK() bool {
+	if r.Err != nil {
+		return false
+	}
+	switch r.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return true
+	}
+	return r.StatusCode < 400
+}
+
+// Reason describes why a probe failed
+func (r ProbeResult) Reason() string {
+	switch {
+	case r.Err != nil:
+		return r.Err.Error()
+	case !r.OK():
+		return fmt.Sprintf("HTTP %d", r.StatusCode)
+	}
+	return ""
+}
+
+// maxRedirects caps the redirects followed for one request
+const maxRedirects = 5
+
+// ErrBlockedAddress is returned when a URL resolves to an address that is
+// not on the public internet
+var ErrBlockedAddress = errors.New("address is not publicly routable")
+
+// reservedPrefixes are special-purpose ranges that the netip predicates do
+// not cover: "this network", carrier-grade NAT and benchmarking
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+}
+
+// publicAddress reports whether ip is a public unicast address
+func publicAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// denyPrivate is a dialer Control that refuses connections to loopback,
+// private, link-local and other non-public addresses. It sees the resolved
+// address, so DNS names pointing inside the network are refused as well.
+func denyPrivate(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !publicAddress(ip) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, ip)
+	}
+	return nil
+}
+
+// NewClient returns an HTTP client for fetching URLs submitted by users. It
+// connects only to public addresses, also after redirects, follows at most
+// maxRedirects redirects and does not use a proxy, which would hide the
+// address it connects to.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: denyPrivate}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+}
+
+// userAgent identifies the checker to the probed sites
+const userAgent = "ChainAppHub-LinkChecker/1.0"
+
+// Probe requests rawURL with client, trying HEAD first and retrying with
+// GET when HEAD gets an error status, since some servers do not support it
+func Probe(ctx context.Context, client *http.Client, rawURL string) ProbeResult {
+	start := time.Now()
+	status, err := request(ctx, client, http.MethodHead, rawURL)
+	if err == nil && status >= 400 {
+		status, err = request(ctx, client, http.MethodGet, rawURL)
+	}
+	return ProbeResult{StatusCode: status, Latency: time.Since(start), Err: err}
+}
+
+// request sends one request and returns its status code
+func request(ctx context.Context, client *http.Client, method, rawURL string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Read a little of the body so the connection can be reused
+	_, _ = io.CopyN(io.Discard, resp.Body, 4<<10)
+	return resp.StatusCode, nil
+}
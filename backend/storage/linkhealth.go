@@ -0,0 +1,315 @@
+package storage
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/blockvantage/chain-app-store/backend/config"
+	"github.com/blockvantage/chain-app-store/backend/storage/linkcheck"
+	"github.com/blockvantage/chain-app-store/backend/utils"
+)
+
+// linkProbeWorkers is the number of links probed concurrently
+const linkProbeWorkers = 4
+
+// LinkChecker periodically probes the links of listed apps and records
+// their health
+type LinkChecker struct {
+	db     *gorm.DB
+	cfg    config.LinkHealthConfig
+	Client *http.Client
+}
+
+// NewLinkChecker returns a checker using the linkHealth configuration
+func NewLinkChecker(db *DB, cfg *config.Config) *LinkChecker {
+	return &LinkChecker{
+		db:     db.DB,
+		cfg:    cfg.LinkHealth,
+		Client: linkcheck.NewClient(cfg.LinkHealth.Timeout()),
+	}
+}
+
+// LinkCheckSummary counts the results of one check run
+type LinkCheckSummary struct {
+	Apps       int `json:"apps"`
+	Links      int `json:"links"`
+	Failed     int `json:"failed"`
+	BrokenApps int `json:"brokenApps"`
+}
+
+// Run checks all links every configured interval until ctx is cancelled.
+// It does nothing if the checker is disabled.
+func (lc *LinkChecker) Run(ctx context.Context) {
+	interval := lc.cfg.Interval()
+	if interval == 0 {
+		return
+	}
+	log.Printf("Link health checker running every %s", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		summary, err := lc.CheckAll(ctx)
+		if err != nil {
+			log.Printf("Link health check failed: %v", err)
+		} else {
+			log.Printf("Link health check: %d links of %d apps, %d failed, %d apps broken",
+				summary.Links, summary.Apps, summary.Failed, summary.BrokenApps)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probeJob is a link to probe and where its result goes
+type probeJob struct {
+	appID  uint
+	link   linkcheck.Link
+	result linkcheck.ProbeResult
+}
+
+// CheckAll probes every link of every visible app once, records the
+// results and updates which apps have broken links
+func (lc *LinkChecker) CheckAll(ctx context.Context) (LinkCheckSummary, error) {
+	var summary LinkCheckSummary
+
+	var apps []App
+	if err := lc.db.Where("hidden = ?", false).Find(&apps).Error; err != nil {
+		return summary, err
+	}
+
+	var jobs []*probeJob
+	for _, app := range apps {
+		for _, link := range appLinks(app) {
+			if link.URL != "" {
+				jobs = append(jobs, &probeJob{appID: app.ID, link: link})
+			}
+		}
+	}
+
+	// Probe in parallel, bounded so a run does not flood slow hosts
+	queue := make(chan *probeJob)
+	var wg sync.WaitGroup
+	for i := 0; i < linkProbeWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				job.result = linkcheck.Probe(ctx, lc.Client, job.link.URL)
+			}
+		}()
+	}
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return summary, err
+	}
+
+	byApp := map[uint][]*probeJob{}
+	for _, job := range jobs {
+		byApp[job.appID] = append(byApp[job.appID], job)
+	}
+
+	now := time.Now()
+	for _, app := range apps {
+		broken, err := lc.recordResults(app, byApp[app.ID], now)
+		if err != nil {
+			return summary, err
+		}
+		summary.Apps++
+		summary.Links += len(byApp[app.ID])
+		for _, job := range byApp[app.ID] {
+			if !job.result.OK() {
+				summary.Failed++
+			}
+		}
+		if broken {
+			summary.BrokenApps++
+		}
+	}
+
+	err := lc.db.Where("created_at < ?", now.Add(-lc.cfg.Retention())).Delete(&LinkCheck{}).Error
+	return summary, err
+}
+
+// recordResults stores the check results of one app and reports whether
+// any of its links is now broken
+func (lc *LinkChecker) recordResults(app App, jobs []*probeJob, now time.Time) (bool, error) {
+	broken := false
+	err := lc.db.Transaction(func(tx *gorm.DB) error {
+		var statuses []LinkStatus
+		if err := tx.Where("app_id = ?", app.ID).Find(&statuses).Error; err != nil {
+			return err
+		}
+		previous := map[string]LinkStatus{}
+		for _, status := range statuses {
+			previous[status.Field] = status
+		}
+
+		fields := []string{}
+		for _, job := range jobs {
+			result := job.result
+			if err := tx.Create(&LinkCheck{
+				AppID:      app.ID,
+				Field:      job.link.Field,
+				URL:        job.link.URL,
+				OK:         result.OK(),
+				StatusCode: result.StatusCode,
+				Error:      result.Reason(),
+				LatencyMs:  result.Latency.Milliseconds(),
+			}).Error; err != nil {
+				return err
+			}
+
+			status := previous[job.link.Field]
+			if status.URL != job.link.URL {
+				// The link was changed, so earlier failures no longer apply
+				status = LinkStatus{AppID: app.ID, Field: job.link.Field, URL: job.link.URL, ID: status.ID}
+			}
+			status.OK = result.OK()
+			status.StatusCode = result.StatusCode
+			status.Error = result.Reason()
+			status.CheckedAt = now
+			if status.OK {
+				status.ConsecutiveFailures = 0
+				checkedAt := now
+				status.LastOKAt = &checkedAt
+			} else {
+				status.ConsecutiveFailures++
+			}
+			status.Broken = status.ConsecutiveFailures >= lc.cfg.BrokenAfter()
+			if status.Broken {
+				broken = true
+			}
+			if err := tx.Save(&status).Error; err != nil {
+				return err
+			}
+			fields = append(fields, job.link.Field)
+		}
+
+		// Forget links that were removed from the app
+		query := tx.Where("app_id = ?", app.ID)
+		if len(fields) > 0 {
+			query = query.Where("field NOT IN ?", fields)
+		}
+		if err := query.Delete(&LinkStatus{}).Error; err != nil {
+			return err
+		}
+
+		if app.LinksBroken == broken {
+			return nil
+		}
+		return tx.Model(&App{}).Where("id = ?", app.ID).Update("links_broken", broken).Error
+	})
+	return broken, err
+}
+
+// GetLinkHealthReport returns a page of apps with failing links and their
+// link statuses. ?status=broken limits it to apps marked broken, and
+// ?status=all includes healthy apps (admin only).
+func GetLinkHealthReport(db *DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := db.Model(&App{})
+		switch c.DefaultQuery("status", "failing") {
+		case "broken":
+			query = query.Where("links_broken = ?", true)
+		case "failing":
+			query = query.Where("id IN (?)", db.Model(&LinkStatus{}).Select("app_id").Where("ok = ?", false))
+		case "all":
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be broken, failing or all"})
+			return
+		}
+
+		page, pageSize, offset := utils.ParsePagination(c)
+
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var apps []App
+		err := query.Select("id", "name", "developer_address", "hidden", "links_broken").
+			Preload("LinkHealth", func(db *gorm.DB) *gorm.DB {
+				return db.Order("field")
+			}).
+			Clauses(clause.OrderBy{Columns: []clause.OrderByColumn{
+				{Column: clause.Column{Name: "links_broken"}, Desc: true},
+				{Column: clause.Column{Name: "id"}},
+			}}).
+			Offset(offset).Limit(pageSize).Find(&apps).Error
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		report := make([]gin.H, len(apps))
+		for i, app := range apps {
+			report[i] = gin.H{
+				"appId":       app.ID,
+				"name":        app.Name,
+				"hidden":      app.Hidden,
+				"linksBroken": app.LinksBroken,
+				"links":       app.LinkHealth,
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"apps":       report,
+			"pagination": utils.PaginationMeta(total, page, pageSize),
+		})
+	}
+}
+
+// GetLinkHistory returns a page of check results of an app's links, newest
+// first, optionally limited to one ?field= (admin only)
+func GetLinkHistory(db *DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		appID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid app ID"})
+			return
+		}
+
+		query := db.Model(&LinkCheck{}).Where("app_id = ?", appID)
+		if field := c.Query("field"); field != "" {
+			query = query.Where("field = ?", field)
+		}
+
+		page, pageSize, offset := utils.ParsePagination(c)
+
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var checks []LinkCheck
+		if err := query.Order("id DESC").Offset(offset).Limit(pageSize).Find(&checks).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"checks":     checks,
+			"pagination": utils.PaginationMeta(total, page, pageSize),
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/blockvantage/chain-app-store/backend/config"
+	"github.com/blockvantage/chain-app-store/backend/storage/linkcheck"
+)
+
+// newLinkTestServer stands in for the sites an app links to. /flaky fails
+// while down is set.
+func newLinkTestServer(t *testing.T, down *atomic.Bool) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/bot-wall", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	mux.HandleFunc("/flaky", func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newLinkTestDB(t *testing.T) (*DB, *config.Config) {
+	cfg := &config.Config{LinkHealth: config.LinkHealthConfig{FailureThreshold: 2}}
+	db, err := InitDB(filepath.Join(t.TempDir(), "links.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RunMigrations(db, cfg); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db, cfg
+}
+
+func linkStatuses(t *testing.T, db *DB, appID uint) map[string]LinkStatus {
+	var statuses []LinkStatus
+	if err := db.Where("app_id = ?", appID).Find(&statuses).Error; err != nil {
+		t.Fatal(err)
+	}
+	byField := map[string]LinkStatus{}
+	for _, status := range statuses {
+		byField[status.Field] = status
+	}
+	return byField
+}
+
+func appLinksBroken(t *testing.T, db *DB, appID uint) bool {
+	var app App
+	if err := db.First(&app, appID).Error; err != nil {
+		t.Fatal(err)
+	}
+	return app.LinksBroken
+}
+
+func TestLinkCheckerMarksPersistentlyBrokenLinks(t *testing.T) {
+	var down atomic.Bool
+	down.Store(true)
+	srv := newLinkTestServer(t, &down)
+	db, cfg := newLinkTestDB(t)
+
+	app := App{
+		Name:       "Example",
+		WebsiteURL: srv.URL + "/ok",
+		RepoURL:    srv.URL + "/no-head",
+		TwitterURL: srv.URL + "/bot-wall",
+		DiscordURL: srv.URL + "/flaky",
+	}
+	if err := db.Create(&app).Error; err != nil {
+		t.Fatal(err)
+	}
+	hidden := App{Name: "Hidden", WebsiteURL: srv.URL + "/gone", Hidden: true}
+	if err := db.Create(&hidden).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	checker := NewLinkChecker(db, cfg)
+	// The test server listens on loopback, which the default client refuses
+	checker.Client = srv.Client()
+	ctx := context.Background()
+
+	// One failure is not enough to mark the app
+	summary, err := checker.CheckAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Apps != 1 || summary.Links != 4 || summary.Failed != 1 || summary.BrokenApps != 0 {
+		t.Fatalf("first run summary = %+v", summary)
+	}
+	statuses := linkStatuses(t, db, app.ID)
+	for _, field := range []string{"websiteUrl", "repoUrl", "twitterUrl"} {
+		if !statuses[field].OK {
+			t.Errorf("%s should be healthy: %+v", field, statuses[field])
+		}
+	}
+	if discord := statuses["discordUrl"]; discord.OK || discord.StatusCode != http.StatusBadGateway || discord.ConsecutiveFailures != 1 || discord.Broken {
+		t.Errorf("discordUrl after one failure = %+v", discord)
+	}
+	if appLinksBroken(t, db, app.ID) {
+		t.Error("app marked broken after a single failure")
+	}
+
+	// A second consecutive failure reaches the threshold
+	if _, err := checker.CheckAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if discord := linkStatuses(t, db, app.ID)["discordUrl"]; discord.ConsecutiveFailures != 2 || !discord.Broken || discord.LastOKAt != nil {
+		t.Errorf("discordUrl after two failures = %+v", discord)
+	}
+	if !appLinksBroken(t, db, app.ID) {
+		t.Error("app not marked broken after repeated failures")
+	}
+
+	// Recovery clears the mark
+	down.Store(false)
+	if _, err := checker.CheckAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if discord := linkStatuses(t, db, app.ID)["discordUrl"]; !discord.OK || discord.ConsecutiveFailures != 0 || discord.Broken || discord.LastOKAt == nil {
+		t.Errorf("discordUrl after recovery = %+v", discord)
+	}
+	if appLinksBroken(t, db, app.ID) {
+		t.Error("app still marked broken after its links recovered")
+	}
+
+	var history int64
+	db.Model(&LinkCheck{}).Where("app_id = ?", app.ID).Count(&history)
+	if history != 12 {
+		t.Errorf("history has %d checks, want 12", history)
+	}
+	db.Model(&LinkCheck{}).Where("app_id = ?", hidden.ID).Count(&history)
+	if history != 0 {
+		t.Errorf("hidden app was checked %d times", history)
+	}
+}
+
+func TestLinkCheckerResetsChangedAndRemovedLinks(t *testing.T) {
+	var down atomic.Bool
+	srv := newLinkTestServer(t, &down)
+	db, cfg := newLinkTestDB(t)
+
+	app := App{Name: "Example", WebsiteURL: srv.URL + "/gone", RepoURL: srv.URL + "/gone"}
+	if err := db.Create(&app).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	checker := NewLinkChecker(db, cfg)
+	// The test server listens on loopback, which the default client refuses
+	checker.Client = srv.Client()
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, err := checker.CheckAll(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !appLinksBroken(t, db, app.ID) {
+		t.Fatal("app not marked broken")
+	}
+
+	// Replacing the website with another dead link starts a new count, and
+	// dropping the repo forgets its status
+	if err := db.Model(&app).Updates(map[string]interface{}{"website_url": srv.URL + "/gone?v=2", "repo_url": ""}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checker.CheckAll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	statuses := linkStatuses(t, db, app.ID)
+	if len(statuses) != 1 {
+		t.Fatalf("statuses = %+v, want only websiteUrl", statuses)
+	}
+	if website := statuses["websiteUrl"]; website.ConsecutiveFailures != 1 || website.Broken {
+		t.Errorf("websiteUrl after change = %+v", website)
+	}
+	if appLinksBroken(t, db, app.ID) {
+		t.Error("app still marked broken after its links changed")
+	}
+}
+
+func TestLinkCheckerRefusesPrivateAddresses(t *testing.T) {
+	var down atomic.Bool
+	srv := newLinkTestServer(t, &down)
+	db, cfg := newLinkTestDB(t)
+
+	checker := NewLinkChecker(db, cfg)
+	result := linkcheck.Probe(context.Background(), checker.Client, srv.URL+"/ok")
+	if !errors.Is(result.Err, linkcheck.ErrBlockedAddress) {
+		t.Fatalf("probe of %s: got %v, want %v", srv.URL, result.Err, linkcheck.ErrBlockedAddress)
+	}
+}
@@ -0,0 +1,199 @@
+package storage
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/blockvantage/chain-app-store/backend/config"
+	"github.com/blockvantage/chain-app-store/backend/storage/linkcheck"
+)
+
+// linkcheckReporter is the reporter address of flags raised by link checks
+const linkcheckReporter = "system:linkcheck"
+
+// appLinks returns the links of an app listing keyed by their JSON field
+func appLinks(app App) []linkcheck.Link {
+	return []linkcheck.Link{
+		{Field: "websiteUrl", URL: app.WebsiteURL},
+		{Field: "repoUrl", URL: app.RepoURL},
+		{Field: "twitterUrl", URL: app.TwitterURL},
+		{Field: "discordUrl", URL: app.DiscordURL},
+		{Field: "telegramUrl", URL: app.TelegramURL},
+		{Field: "mediumUrl", URL: app.MediumURL},
+		{Field: "githubUrl", URL: app.GithubURL},
+	}
+}
+
+// phishingBlocklist returns the configured blocklist, or nil if none is set
+func phishingBlocklist(cfg *config.Config) *linkcheck.Blocklist {
+	if cfg.Moderation.PhishingBlocklist == "" {
+		return nil
+	}
+	return linkcheck.OpenBlocklist(cfg.Moderation.PhishingBlocklist)
+}
+
+// EnforceLinkRules rejects the request with 400 if a link of app is
+// malformed or a social link points at the wrong platform, and with 403 if
+// a link is on the phishing blocklist. It reports whether the request may
+// continue.
+func EnforceLinkRules(c *gin.Context, cfg *config.Config, app App) bool {
+	links := appLinks(app)
+	if linkErr := linkcheck.Validate(links); linkErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid link: " + linkErr.Error(), "link": linkErr})
+		return false
+	}
+
+	blocklist := phishingBlocklist(cfg)
+	if blocklist == nil {
+		return true
+	}
+	for _, link := range links {
+		if link.URL == "" {
+			continue
+		}
+		u, _ := linkcheck.Parse(link.URL)
+		domain, err := blocklist.Match(linkcheck.Host(u))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check phishing blocklist"})
+			return false
+		}
+		if domain != "" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": fmt.Sprintf("%s is on a known phishing domain", link.Field),
+				"link":  linkcheck.LinkError{Field: link.Field, URL: link.URL, Reason: "phishing domain " + domain},
+			})
+			return false
+		}
+	}
+	return true
+}
+
+// findLookalikes returns listed apps of other developers whose website
+// domain resembles the website of app
+func findLookalikes(db *gorm.DB, app App) ([]App, error) {
+	u, err := linkcheck.Parse(app.WebsiteURL)
+	if err != nil {
+		return nil, nil
+	}
+	host := linkcheck.Host(u)
+
+	var listed []App
+	err = db.Select("id", "name", "website_url", "developer_address").
+		Where("hidden = ? AND website_url <> '' AND id <> ?", false, app.ID).
+		Find(&listed).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []App
+	for _, other := range listed {
+		if strings.EqualFold(other.DeveloperAddress, app.DeveloperAddress) {
+			continue
+		}
+		otherURL, err := linkcheck.Parse(other.WebsiteURL)
+		if err != nil {
+			continue
+		}
+		if linkcheck.IsLookalike(host, linkcheck.Host(otherURL)) {
+			matches = append(matches, other)
+		}
+	}
+	return matches, nil
+}
+
+// FlagLookalikeApp raises an impersonation flag on app when its website
+// domain resembles that of another listed app, so moderators review it.
+// It returns the IDs of the resembled apps.
+func FlagLookalikeApp(db *gorm.DB, app App) ([]uint, error) {
+	matches, err := findLookalikes(db, app)
+	if err != nil || len(matches) == 0 {
+		return nil, err
+	}
+
+	ids := make([]uint, len(matches))
+	details := make([]string, len(matches))
+	for i, match := range matches {
+		ids[i] = match.ID
+		details[i] = fmt.Sprintf("Website %s resembles %s of app %d (%s)", app.WebsiteURL, match.WebsiteURL, match.ID, match.Name)
+	}
+
+	flag := Flag{
+		Type:            FlagTypeApp,
+		ContentID:       app.ID,
+		ReporterAddress: linkcheckReporter,
+		Reason:          "impersonation",
+		Details:         strings.Join(details, "\n"),
+		RelatedIDs:      ids,
+	}
+	return ids, db.Create(&flag).Error
+}
+
+// GetPhishingBlocklist returns the domains on the phishing blocklist
+// (admin only)
+func GetPhishingBlocklist(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		blocklist := phishingBlocklist(cfg)
+		if blocklist == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "phishing blocklist is not configured"})
+			return
+		}
+
+		domains, err := blocklist.Domains()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read phishing blocklist"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"domains": domains, "count": len(domains)})
+	}
+}
+
+// UpdatePhishingBlocklist adds domains to and removes domains from the
+// phishing blocklist file (admin only)
+func UpdatePhishingBlocklist(db *DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Add    []string `json:"add"`
+			Remove []string `json:"remove"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		blocklist := phishingBlocklist(cfg)
+		if blocklist == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "phishing blocklist is not configured"})
+			return
+		}
+
+		for _, domain := range req.Add {
+			if _, err := normalizeBanValue(BanDomain, domain); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		added, removed, err := blocklist.Update(req.Add, req.Remove)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update phishing blocklist"})
+			return
+		}
+
+		if len(added) > 0 || len(removed) > 0 {
+			err := RecordAudit(db.DB, c, "blocklist.update", "blocklist", 0,
+				gin.H{"removed": removed}, gin.H{"added": added})
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record audit entry"})
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"added": added, "removed": removed})
+	}
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"encoding/json"
 	"time"
 
 	"gorm.io/gorm"
@@ -20,7 +21,13 @@ type App struct {
 	Featured      bool `json:"featured" gorm:"index"`
 	Hidden        bool `json:"hidden" gorm:"index"`
 	LogoPath      string `json:"logoPath"`
+	LogoHash      string `json:"-"` // Perceptual hash of the logo for duplicate detection
+	LogoVariants  map[string]string `json:"logoVariants,omitempty" gorm:"serializer:json"` // Resized logos keyed by size, see filestore.LogoVariants
 	MockupImages  []AppImage `json:"mockupImages" gorm:"foreignKey:AppID"`
+	LinksBroken   bool `json:"linksBroken" gorm:"index"` // A link failed repeated health checks
+	Verified      bool `json:"verified" gorm:"index"` // Developer proved control of every listed contract on-chain, see DeveloperProof
+	DomainVerified bool `json:"domainVerified"` // Developer proved control of the website domain
+	LinkHealth    []LinkStatus `json:"linkHealth,omitempty" gorm:"foreignKey:AppID"`
 	
 	// Social media links
 	TwitterURL   string `json:"twitterUrl"`
@@ -36,10 +43,33 @@ type AppImage struct {
 	AppID       uint   `json:"appId" gorm:"index"`
 	Filename    string `json:"filename"`
 	ImagePath   string `json:"imagePath"`
+	Variants    map[string]string `json:"variants,omitempty" gorm:"serializer:json"` // Resized copies keyed by name, see filestore.MockupVariants
 	Description string `json:"description"`                      // Optional description of the image
 	Order       int    `json:"order"`                           // Display order
 }
 
+// AppContract indexes the contract addresses of apps for reverse lookup
+type AppContract struct {
+	ID                 uint       `json:"-" gorm:"primarykey"`
+	AppID              uint       `json:"appId" gorm:"index;uniqueIndex:idx_app_contract"`
+	Address            string     `json:"address" gorm:"index;uniqueIndex:idx_app_contract"` // Checksummed
+	Verified           bool       `json:"verified"` // The developer proved control of the contract
+	VerificationMethod string     `json:"verificationMethod,omitempty"` // deployer, owner, dns or wellknown
+	VerifiedAt         *time.Time `json:"verifiedAt,omitempty"`
+}
+
+// DeveloperProof records a successful proof that an app's developer
+// controls its contracts or website
+type DeveloperProof struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time `json:"createdAt"`
+	AppID     uint      `json:"appId" gorm:"index"`
+	Method    string    `json:"method"`  // deployer, owner, dns or wellknown
+	Subject   string    `json:"subject"` // Contract address or website domain
+	Evidence  string    `json:"evidence"` // Creation tx hash, owner() result or published signature
+	Signature string    `json:"signature"` // Developer's signature of the verification request
+}
+
 // Transaction represents a blockchain transaction
 type Transaction struct {
 	gorm.Model
@@ -56,12 +86,107 @@ type Transaction struct {
 // Flag represents a moderation flag for content
 type Flag struct {
 	gorm.Model
-	Type          string `json:"type" gorm:"index"` // app, review, etc.
-	ContentID     uint   `json:"contentId" gorm:"index"`
-	ReporterAddress string `json:"reporterAddress"`
-	Reason        string `json:"reason"`
+	Type          string `json:"type" gorm:"index;uniqueIndex:idx_flag_reporter"` // app, review, etc.
+	ContentID     uint   `json:"contentId" gorm:"index;uniqueIndex:idx_flag_reporter"`
+	ReporterAddress string `json:"reporterAddress" gorm:"uniqueIndex:idx_flag_reporter"`
+	Reason        string `json:"reason"` // Reason code, see FlagReasons
+	Details       string `json:"details"`
+	RelatedIDs    []uint `json:"relatedIds,omitempty" gorm:"serializer:json"` // Apps the flagged content conflicts with, for system flags
+	Signature     string `json:"signature"`
 	Resolved      bool   `json:"resolved" gorm:"index"`
+	Dismissed     bool   `json:"dismissed"` // Resolved without action
 	ResolvedBy    string `json:"resolvedBy"`
+	ResolvedAt    *time.Time `json:"resolvedAt"`
+}
+
+// LinkStatus is the latest health check result of one link of an app
+type LinkStatus struct {
+	ID                  uint       `json:"-" gorm:"primarykey"`
+	AppID               uint       `json:"appId" gorm:"uniqueIndex:idx_link_status_field"`
+	Field               string     `json:"field" gorm:"uniqueIndex:idx_link_status_field"` // websiteUrl, repoUrl, twitterUrl, etc.
+	URL                 string     `json:"url"`
+	OK                  bool       `json:"ok"`
+	StatusCode          int        `json:"statusCode"`
+	Error               string     `json:"error,omitempty"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	Broken              bool       `json:"broken" gorm:"index"` // Failures reached the configured threshold
+	CheckedAt           time.Time  `json:"checkedAt"`
+	LastOKAt            *time.Time `json:"lastOkAt"`
+}
+
+// LinkCheck is one health check result, kept as history
+type LinkCheck struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	CreatedAt  time.Time `json:"createdAt" gorm:"index"`
+	AppID      uint      `json:"appId" gorm:"index"`
+	Field      string    `json:"field"`
+	URL        string    `json:"url"`
+	OK         bool      `json:"ok"`
+	StatusCode int       `json:"statusCode"`
+	Error      string    `json:"error,omitempty"`
+	LatencyMs  int64     `json:"latencyMs"`
+}
+
+// Ban blocks a wallet, contract address or website domain
+type Ban struct {
+	gorm.Model
+	Kind          string     `json:"kind" gorm:"uniqueIndex:idx_ban_value"` // wallet, contract or domain
+	Value         string     `json:"value" gorm:"uniqueIndex:idx_ban_value"` // Checksummed address or lowercase domain
+	Reason        string     `json:"reason"`
+	ExpiresAt     *time.Time `json:"expiresAt" gorm:"index"` // Nil for a permanent ban
+	CreatedBy     string     `json:"createdBy"`
+}
+
+// AdminRole grants an admin wallet a role, see RolePermissions
+type AdminRole struct {
+	gorm.Model
+	Address       string `json:"address" gorm:"uniqueIndex"` // Checksummed wallet address
+	Role          string `json:"role" gorm:"index"`
+	GrantedBy     string `json:"grantedBy"` // Admin wallet, or "config" for seeded super-admins
+}
+
+// AdminNonce records a nonce consumed by a signed admin request so the same
+// request cannot be replayed
+type AdminNonce struct {
+	gorm.Model
+	Address       string `json:"address" gorm:"uniqueIndex:idx_admin_nonce"`
+	Nonce         string `json:"nonce" gorm:"uniqueIndex:idx_admin_nonce"`
+}
+
+// AdminProposal is an admin action that runs once enough admins sign it
+type AdminProposal struct {
+	gorm.Model
+	Action        string          `json:"action" gorm:"index"`
+	Params        json.RawMessage `json:"params"` // Compact JSON parameters, as signed
+	ProposedBy    string          `json:"proposedBy" gorm:"index"`
+	Status        string          `json:"status" gorm:"index"` // pending, executed, failed, expired, cancelled
+	Threshold     int             `json:"threshold"`
+	ExpiresAt     time.Time       `json:"expiresAt" gorm:"index"`
+	ExecutedAt    *time.Time      `json:"executedAt"`
+	Result        string          `json:"result"` // Outcome or error of the execution
+	Approvals     []ProposalApproval `json:"approvals" gorm:"foreignKey:ProposalID"`
+}
+
+// ProposalApproval is one admin's signature on a proposal
+type ProposalApproval struct {
+	gorm.Model
+	ProposalID    uint   `json:"proposalId" gorm:"uniqueIndex:idx_proposal_admin"`
+	AdminAddress  string `json:"adminAddress" gorm:"uniqueIndex:idx_proposal_admin"`
+	Signature     string `json:"signature"`
+}
+
+// AuditEntry is an append-only record of an admin action. It has no update
+// or delete timestamps because entries are never changed.
+type AuditEntry struct {
+	ID           uint            `json:"id" gorm:"primaryKey"`
+	CreatedAt    time.Time       `json:"createdAt" gorm:"index"`
+	AdminAddress string          `json:"adminAddress" gorm:"index"` // Recovered from the admin signature
+	Action       string          `json:"action" gorm:"index"`       // e.g. app.hide, review.hide
+	TargetType   string          `json:"targetType" gorm:"index:idx_audit_target"`
+	TargetID     uint            `json:"targetId" gorm:"index:idx_audit_target"`
+	Before       json.RawMessage `json:"before"` // Changed fields before the action, as JSON
+	After        json.RawMessage `json:"after"`  // Changed fields after the action, as JSON
+	Reason       string          `json:"reason"`
 }
 
 // Review represents a user review of an app (only if reviews module is enabled)
@@ -72,7 +197,45 @@ type Review struct {
 	Rating        int    `json:"rating" gorm:"index"` // 1-5 stars
 	Comment       string `json:"comment"`
 	Signature     string `json:"signature"` // Signature to verify the review is from the user
+	Revision      int    `json:"revision"` // Signed revision number, 0 for reviews signed before comments were covered
 	Hidden        bool   `json:"hidden" gorm:"index"`
+	HelpfulCount   int     `json:"helpfulCount"`
+	UnhelpfulCount int     `json:"unhelpfulCount"`
+	HelpfulScore   float64 `json:"helpfulScore" gorm:"index"` // Wilson lower bound of the helpful ratio
+	Verified       bool    `json:"verified" gorm:"index"` // Reviewer has transacted with the app's contracts
+	VerifiedTxHash string  `json:"verifiedTxHash,omitempty"` // Transaction that proved the interaction, if any
+	Reply         *ReviewReply `json:"reply,omitempty" gorm:"foreignKey:ReviewID"`
+}
+
+// ReviewRevision is one signed version of a review, kept as edit history
+type ReviewRevision struct {
+	gorm.Model
+	ReviewID      uint   `json:"reviewId" gorm:"uniqueIndex:idx_review_revision"`
+	Revision      int    `json:"revision" gorm:"uniqueIndex:idx_review_revision"`
+	Rating        int    `json:"rating"`
+	Comment       string `json:"comment"`
+	Signature     string `json:"signature"`
+}
+
+// ReviewVote is a signed helpful or unhelpful vote on a review
+type ReviewVote struct {
+	gorm.Model
+	ReviewID      uint   `json:"reviewId" gorm:"uniqueIndex:idx_review_voter"`
+	VoterAddress  string `json:"voterAddress" gorm:"uniqueIndex:idx_review_voter"`
+	Helpful       bool   `json:"helpful"`
+	Signature     string `json:"signature"`
+	Revision      int    `json:"revision"` // Signed revision number, bumped by each change of vote
+}
+
+// ReviewReply is the app developer's public response to a review
+type ReviewReply struct {
+	gorm.Model
+	ReviewID         uint   `json:"reviewId" gorm:"uniqueIndex"`
+	DeveloperAddress string `json:"developerAddress" gorm:"index"`
+	Comment          string `json:"comment"`
+	Signature        string `json:"signature"` // Signature over the reply text by the developer
+	Revision         int    `json:"revision"`  // Signed revision number, 0 for replies signed before edits were covered
+	Hidden           bool   `json:"hidden" gorm:"index"`
 }
 
 // Point represents a POE (Proof of Engagement) point (only if POE module is enabled)
@@ -83,6 +246,63 @@ type Point struct {
 	Amount        int    `json:"amount"`
 	Action        string `json:"action"` // visit, use, share, etc.
 	TxHash        string `json:"txHash"` // Optional: transaction hash if relevant
+	Verified      bool   `json:"verified"` // TxHash was confirmed on-chain
+}
+
+// Season is a time-bounded POE campaign with its own leaderboard
+type Season struct {
+	gorm.Model
+	Name          string     `json:"name"`
+	StartsAt      time.Time  `json:"startsAt" gorm:"index"`
+	EndsAt        time.Time  `json:"endsAt" gorm:"index"`
+	Closed        bool       `json:"closed" gorm:"index"`
+	ClosedAt      *time.Time `json:"closedAt"`
+}
+
+// SeasonStanding is a frozen leaderboard row persisted when a season closes
+type SeasonStanding struct {
+	gorm.Model
+	SeasonID      uint   `json:"seasonId" gorm:"index"`
+	Rank          int    `json:"rank"`
+	UserAddress   string `json:"userAddress" gorm:"index"`
+	Total         int    `json:"total"`
+}
+
+// RewardDistribution is a Merkle token distribution computed from POE points
+type RewardDistribution struct {
+	gorm.Model
+	Name           string    `json:"name"`
+	SeasonID       *uint     `json:"seasonId" gorm:"index"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	Formula        string    `json:"formula"` // proportional, tiered, capped
+	Params         string    `json:"params"`  // formula parameters as JSON
+	TokenSymbol    string    `json:"tokenSymbol"`
+	Budget         string    `json:"budget"`         // total budget in base units
+	TotalAllocated string    `json:"totalAllocated"` // sum of allocations in base units
+	Recipients     int       `json:"recipients"`
+	MerkleRoot     string    `json:"merkleRoot" gorm:"index"`
+	Approved       bool      `json:"approved"` // Approved for payout through a multisig proposal
+	ApprovedAt     *time.Time `json:"approvedAt"`
+}
+
+// RewardAllocation is one claim in a reward distribution
+type RewardAllocation struct {
+	gorm.Model
+	DistributionID uint     `json:"distributionId" gorm:"index;uniqueIndex:idx_allocation_account"`
+	Index          uint64   `json:"index"`
+	Account        string   `json:"account" gorm:"uniqueIndex:idx_allocation_account"`
+	Points         int      `json:"points"`
+	Amount         string   `json:"amount"` // base units
+	Proof          []string `json:"proof" gorm:"serializer:json"`
+}
+
+// EngagementNonce records a nonce consumed by a signed engagement so the
+// same signature cannot be replayed
+type EngagementNonce struct {
+	gorm.Model
+	UserAddress   string `json:"userAddress" gorm:"uniqueIndex:idx_engagement_nonce"`
+	Nonce         string `json:"nonce" gorm:"uniqueIndex:idx_engagement_nonce"`
 }
 
 // Boost represents a boost for an app (only if boosting module is enabled)
@@ -95,3 +315,48 @@ type Boost struct {
 	TxHash        string `json:"txHash" gorm:"uniqueIndex"`
 	ExpiresAt     time.Time `json:"expiresAt" gorm:"index"`
 }
+
+// UserPointTotal is the all-time POE total of a user, maintained alongside
+// every Point insert
+type UserPointTotal struct {
+	UserAddress   string    `json:"userAddress" gorm:"primaryKey"`
+	Total         int       `json:"total" gorm:"index"`
+	Count         int       `json:"count"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// AppUserPointTotal is the all-time POE total of a user on one app
+type AppUserPointTotal struct {
+	AppID         uint      `json:"appId" gorm:"primaryKey"`
+	UserAddress   string    `json:"userAddress" gorm:"primaryKey"`
+	Total         int       `json:"total" gorm:"index"`
+	Count         int       `json:"count"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// AppRatingStat holds the rating sums and counts of an app's visible reviews,
+// overall and from verified reviewers only
+type AppRatingStat struct {
+	AppID         uint      `json:"appId" gorm:"primaryKey"`
+	RatingSum     int       `json:"ratingSum"`
+	RatingCount   int       `json:"ratingCount"`
+	VerifiedSum   int       `json:"verifiedSum"`
+	VerifiedCount int       `json:"verifiedCount"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// Average returns the mean rating, or zero when there are no ratings
+func (s AppRatingStat) Average() float64 {
+	if s.RatingCount == 0 {
+		return 0
+	}
+	return float64(s.RatingSum) / float64(s.RatingCount)
+}
+
+// VerifiedAverage returns the mean rating of verified reviewers
+func (s AppRatingStat) VerifiedAverage() float64 {
+	if s.VerifiedCount == 0 {
+		return 0
+	}
+	return float64(s.VerifiedSum) / float64(s.VerifiedCount)
+}
@@ -0,0 +1,675 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/blockvantage/chain-app-store/backend/config"
+	"github.com/blockvantage/chain-app-store/backend/utils"
+)
+
+// Proposal statuses
+const (
+	ProposalPending   = "pending"
+	ProposalExecuted  = "executed"
+	ProposalFailed    = "failed"
+	ProposalExpired   = "expired"
+	ProposalCancelled = "cancelled"
+)
+
+// maxBatchItems caps how much content one hide proposal can change
+const maxBatchItems = 500
+
+// errInvalidParams is returned when proposal parameters are malformed
+var errInvalidParams = errors.New("invalid params")
+
+// proposalAction is an admin action that runs through a multisig proposal
+type proposalAction struct {
+	// permission is required both to propose and to approve the action
+	permission string
+	// validate checks the parameters when the action is proposed
+	validate func(db *gorm.DB, params json.RawMessage) error
+	// execute runs the action and returns a summary of what it changed
+	execute func(tx *gorm.DB, c *gin.Context, p *AdminProposal) (string, error)
+}
+
+// proposalActions are the actions that need multisig approval
+var proposalActions = map[string]proposalAction{
+	"app.delist": {
+		permission: PermHideContent,
+		validate:   validateDelist,
+		execute:    executeDelist,
+	},
+	"content.hide-batch": {
+		permission: PermHideContent,
+		validate:   validateHideBatch,
+		execute:    executeHideBatch,
+	},
+	"distribution.approve": {
+		permission: PermManageSettlements,
+		validate:   validateDistributionApproval,
+		execute:    executeDistributionApproval,
+	},
+	"admin.role": {
+		permission: PermManageAdmins,
+		validate:   validateAdminRole,
+		execute:    executeAdminRole,
+	},
+}
+
+// delistParams are the parameters of app.delist
+type delistParams struct {
+	AppID uint `json:"appId"`
+}
+
+// hideBatchParams are the parameters of content.hide-batch
+type hideBatchParams struct {
+	Items []struct {
+		Type      string `json:"type"`
+		ContentID uint   `json:"contentId"`
+	} `json:"items"`
+	Hidden bool `json:"hidden"`
+}
+
+// distributionApprovalParams are the parameters of distribution.approve
+type distributionApprovalParams struct {
+	DistributionID uint `json:"distributionId"`
+}
+
+// adminRoleParams are the parameters of admin.role
+type adminRoleParams struct {
+	Address string `json:"address"`
+	Role    string `json:"role"`
+}
+
+// decodeParams strictly decodes proposal parameters into v
+func decodeParams(params json.RawMessage, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(params))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidParams, err)
+	}
+	return nil
+}
+
+func validateDelist(db *gorm.DB, params json.RawMessage) error {
+	var p delistParams
+	if err := decodeParams(params, &p); err != nil {
+		return err
+	}
+	if err := db.First(&App{}, p.AppID).Error; err != nil {
+		return fmt.Errorf("%w: app %d not found", errInvalidParams, p.AppID)
+	}
+	return nil
+}
+
+// executeDelist removes an app from the store
+func executeDelist(tx *gorm.DB, c *gin.Context, proposal *AdminProposal) (string, error) {
+	var p delistParams
+	if err := decodeParams(proposal.Params, &p); err != nil {
+		return "", err
+	}
+	var app App
+	if err := tx.First(&app, p.AppID).Error; err != nil {
+		return "", fmt.Errorf("app %d not found", p.AppID)
+	}
+	if err := tx.Delete(&app).Error; err != nil {
+		return "", err
+	}
+	if err := RecordAudit(tx, c, "app.delist", FlagTypeApp, app.ID,
+		gin.H{"listed": true}, gin.H{"listed": false, "proposalId": proposal.ID}); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("delisted app %d", app.ID), nil
+}
+
+func validateHideBatch(db *gorm.DB, params json.RawMessage) error {
+	var p hideBatchParams
+	if err := decodeParams(params, &p); err != nil {
+		return err
+	}
+	if len(p.Items) == 0 || len(p.Items) > maxBatchItems {
+		return fmt.Errorf("%w: items must hold 1 to %d entries", errInvalidParams, maxBatchItems)
+	}
+	for _, item := range p.Items {
+		model := contentModel(db, item.Type)
+		if model == nil {
+			return fmt.Errorf("%w: unknown content type %q", errInvalidParams, item.Type)
+		}
+		if err := db.First(model, item.ContentID).Error; err != nil {
+			return fmt.Errorf("%w: %s %d not found", errInvalidParams, item.Type, item.ContentID)
+		}
+	}
+	return nil
+}
+
+// executeHideBatch hides or unhides every listed item
+func executeHideBatch(tx *gorm.DB, c *gin.Context, proposal *AdminProposal) (string, error) {
+	var p hideBatchParams
+	if err := decodeParams(proposal.Params, &p); err != nil {
+		return "", err
+	}
+	for _, item := range p.Items {
+		if err := SetContentHidden(tx, item.Type, item.ContentID, p.Hidden); err != nil {
+			return "", fmt.Errorf("%s %d: %w", item.Type, item.ContentID, err)
+		}
+	}
+	if err := RecordAudit(tx, c, "content.hide-batch", "proposal", proposal.ID, nil, proposal.Params); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("set hidden=%t on %d items", p.Hidden, len(p.Items)), nil
+}
+
+func validateDistributionApproval(db *gorm.DB, params json.RawMessage) error {
+	var p distributionApprovalParams
+	if err := decodeParams(params, &p); err != nil {
+		return err
+	}
+	if !db.Migrator().HasTable(&RewardDistribution{}) {
+		return fmt.Errorf("%w: reward distributions are not enabled", errInvalidParams)
+	}
+	var distribution RewardDistribution
+	if err := db.First(&distribution, p.DistributionID).Error; err != nil {
+		return fmt.Errorf("%w: distribution %d not found", errInvalidParams, p.DistributionID)
+	}
+	if distribution.Approved {
+		return fmt.Errorf("%w: distribution %d is already approved", errInvalidParams, p.DistributionID)
+	}
+	return nil
+}
+
+// executeDistributionApproval approves a reward distribution for payout
+func executeDistributionApproval(tx *gorm.DB, c *gin.Context, proposal *AdminProposal) (string, error) {
+	var p distributionApprovalParams
+	if err := decodeParams(proposal.Params, &p); err != nil {
+		return "", err
+	}
+	now := time.Now()
+	result := tx.Model(&RewardDistribution{}).
+		Where("id = ? AND approved = ?", p.DistributionID, false).
+		Updates(map[string]interface{}{"approved": true, "approved_at": now})
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		return "", fmt.Errorf("distribution %d not found or already approved", p.DistributionID)
+	}
+	if err := RecordAudit(tx, c, "distribution.approve", "distribution", p.DistributionID,
+		gin.H{"approved": false}, gin.H{"approved": true, "proposalId": proposal.ID}); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("approved distribution %d", p.DistributionID), nil
+}
+
+func validateAdminRole(db *gorm.DB, params json.RawMessage) error {
+	var p adminRoleParams
+	if err := decodeParams(params, &p); err != nil {
+		return err
+	}
+	if !common.IsHexAddress(p.Address) {
+		return fmt.Errorf("%w: invalid address", errInvalidParams)
+	}
+	if _, ok := RolePermissions[p.Role]; p.Role != "" && !ok {
+		return fmt.Errorf("%w: unknown role %s", errInvalidParams, p.Role)
+	}
+	return nil
+}
+
+// executeAdminRole grants, changes or, with an empty role, revokes the role
+// of an admin wallet
+func executeAdminRole(tx *gorm.DB, c *gin.Context, proposal *AdminProposal) (string, error) {
+	var p adminRoleParams
+	if err := decodeParams(proposal.Params, &p); err != nil {
+		return "", err
+	}
+	address := utils.NormalizeAddress(p.Address)
+	role, before, err := setAdminRole(tx, address, p.Role, proposal.ProposedBy)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", fmt.Errorf("%s has no admin role", address)
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := RecordAudit(tx, c, "admin.role", "admin", role.ID,
+		gin.H{"address": address, "role": before}, gin.H{"address": address, "role": p.Role, "proposalId": proposal.ID}); err != nil {
+		return "", err
+	}
+	if p.Role == "" {
+		return fmt.Sprintf("revoked the admin role of %s", address), nil
+	}
+	return fmt.Sprintf("set the admin role of %s to %s", address, p.Role), nil
+}
+
+// proposalMessage builds the text an admin signs to propose an action
+func proposalMessage(action string, params json.RawMessage) string {
+	return "Propose admin action " + action + "\nParams: " + string(params)
+}
+
+// approvalMessage builds the text an admin signs to approve a proposal
+func approvalMessage(p *AdminProposal) string {
+	return fmt.Sprintf("Approve admin proposal %d\nAction: %s\nParams: %s", p.ID, p.Action, p.Params)
+}
+
+// approvingRoles lists the roles that grant permission
+func approvingRoles(permission string) []string {
+	var roles []string
+	for role := range RolePermissions {
+		if RoleHasPermission(role, permission) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// eligibleApprovers counts the admins whose role grants permission
+func eligibleApprovers(db *gorm.DB, permission string) (int64, error) {
+	var count int64
+	err := db.Model(&AdminRole{}).Where("role IN ?", approvingRoles(permission)).Count(&count).Error
+	return count, err
+}
+
+// expireProposals marks pending proposals past their expiry as expired
+func expireProposals(db *gorm.DB) error {
+	return db.Model(&AdminProposal{}).
+		Where("status = ? AND expires_at < ?", ProposalPending, time.Now()).
+		Update("status", ProposalExpired).Error
+}
+
+// requireActionPermission checks that the calling admin may sign the action
+func requireActionPermission(c *gin.Context, db *DB, action proposalAction) bool {
+	role, err := GetAdminRole(db.DB, AdminAddress(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if !RoleHasPermission(role, action.permission) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "role " + role + " lacks permission " + action.permission})
+		return false
+	}
+	return true
+}
+
+// errProposalClosed is returned when a proposal stopped being pending after
+// it was loaded
+var errProposalClosed = errors.New("proposal is no longer pending")
+
+// updatePendingProposal updates a proposal only while it is pending. Of two
+// concurrent writers, the first holds SQLite's write lock until it commits
+// and the second then finds the proposal closed and gets errProposalClosed.
+func updatePendingProposal(tx *gorm.DB, id uint, fields map[string]interface{}) error {
+	result := tx.Model(&AdminProposal{}).Where("id = ? AND status = ?", id, ProposalPending).Updates(fields)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errProposalClosed
+	}
+	return nil
+}
+
+// executeIfApproved runs a proposal once it has enough approvals. Only
+// approvals by admins whose role still grants the action's permission count,
+// so signatures of revoked or demoted admins are ignored. The action runs in
+// a nested transaction, so a failing action is rolled back while the
+// proposal is kept as failed.
+func executeIfApproved(tx *gorm.DB, c *gin.Context, p *AdminProposal) error {
+	var approvals int64
+	if err := tx.Model(&ProposalApproval{}).
+		Joins("JOIN admin_roles ON admin_roles.address = proposal_approvals.admin_address").
+		Where("proposal_approvals.proposal_id = ? AND admin_roles.role IN ?", p.ID, approvingRoles(proposalActions[p.Action].permission)).
+		Count(&approvals).Error; err != nil {
+		return err
+	}
+	if approvals < int64(p.Threshold) {
+		return nil
+	}
+
+	// Claim the proposal before running it, so a concurrent approval that
+	// also reached the threshold cannot execute it a second time
+	now := time.Now()
+	if err := updatePendingProposal(tx, p.ID, map[string]interface{}{
+		"status":      ProposalExecuted,
+		"executed_at": now,
+	}); err != nil {
+		return err
+	}
+
+	var summary string
+	err := tx.Transaction(func(inner *gorm.DB) error {
+		var err error
+		summary, err = proposalActions[p.Action].execute(inner, c, p)
+		return err
+	})
+
+	p.ExecutedAt = &now
+	p.Status, p.Result = ProposalExecuted, summary
+	if err != nil {
+		p.Status, p.Result = ProposalFailed, err.Error()
+	}
+	if err := tx.Model(&AdminProposal{}).Where("id = ?", p.ID).Updates(map[string]interface{}{
+		"status": p.Status,
+		"result": p.Result,
+	}).Error; err != nil {
+		return err
+	}
+	return RecordAudit(tx, c, "proposal."+p.Status, "proposal", p.ID, nil, gin.H{"result": p.Result})
+}
+
+// directHideActions are the audited actions that hide content without a
+// proposal
+var directHideActions = []string{"app.hide", "review.hide", "reply.hide", "flag.resolve"}
+
+// LimitDirectHides caps how much content one admin can hide per hour
+// without a proposal, so a single admin cannot hide everything. Larger
+// changes go through a content.hide-batch proposal. It must run after
+// AdminAuthMiddleware.
+func LimitDirectHides(db *DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := cfg.Moderation.DirectHideLimit()
+		if limit == 0 {
+			c.Next()
+			return
+		}
+
+		var recent int64
+		if err := db.Model(&AuditEntry{}).
+			Where("admin_address = ? AND action IN ? AND created_at > ?", AdminAddress(c), directHideActions, time.Now().Add(-time.Hour)).
+			Count(&recent).Error; err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if recent >= int64(limit) {
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": fmt.Sprintf("at most %d direct hides per hour, propose content.hide-batch instead", limit)})
+			return
+		}
+		c.Next()
+	}
+}
+
+// withApprovals preloads proposal approvals in the order they were given
+func withApprovals(db *gorm.DB) *gorm.DB {
+	return db.Preload("Approvals", func(tx *gorm.DB) *gorm.DB {
+		return tx.Order("id")
+	})
+}
+
+// loadProposal loads the proposal named by the :id parameter with its approvals
+func loadProposal(c *gin.Context, db *DB) (*AdminProposal, bool) {
+	if err := expireProposals(db.DB); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	var proposal AdminProposal
+	if err := withApprovals(db.DB).First(&proposal, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "proposal not found"})
+		return nil, false
+	}
+	return &proposal, true
+}
+
+// CreateProposal proposes an action that needs multisig approval. The
+// proposer's signature over "Propose admin action <action>\nParams: <params>",
+// with params as compact JSON, counts as the first approval (admin only).
+func CreateProposal(db *DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Action    string          `json:"action" binding:"required"`
+			Params    json.RawMessage `json:"params" binding:"required"`
+			Signature string          `json:"signature" binding:"required"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		action, ok := proposalActions[req.Action]
+		if !ok {
+			names := make([]string, 0, len(proposalActions))
+			for name := range proposalActions {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown action " + req.Action, "actions": names})
+			return
+		}
+		if !requireActionPermission(c, db, action) {
+			return
+		}
+
+		var params bytes.Buffer
+		if err := json.Compact(&params, req.Params); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "params must be JSON"})
+			return
+		}
+		if err := action.validate(db.DB, params.Bytes()); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		proposer := AdminAddress(c)
+		valid, err := utils.VerifySignature(proposer, req.Signature, proposalMessage(req.Action, params.Bytes()))
+		if err != nil || !valid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+			return
+		}
+
+		// The threshold must be reachable by the admins who can approve
+		threshold := cfg.Multisig.RequiredApprovals()
+		eligible, err := eligibleApprovers(db.DB, action.permission)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if eligible < int64(threshold) {
+			c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%d approvals are required but only %d admins can approve %s", threshold, eligible, req.Action)})
+			return
+		}
+
+		proposal := AdminProposal{
+			Action:     req.Action,
+			Params:     params.Bytes(),
+			ProposedBy: proposer,
+			Status:     ProposalPending,
+			Threshold:  threshold,
+			ExpiresAt:  time.Now().Add(cfg.Multisig.Expiry()),
+		}
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&proposal).Error; err != nil {
+				return err
+			}
+			approval := ProposalApproval{ProposalID: proposal.ID, AdminAddress: proposer, Signature: req.Signature}
+			if err := tx.Create(&approval).Error; err != nil {
+				return err
+			}
+			if err := RecordAudit(tx, c, "proposal.create", "proposal", proposal.ID, nil, proposal); err != nil {
+				return err
+			}
+			return executeIfApproved(tx, c, &proposal)
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create proposal"})
+			return
+		}
+
+		withApprovals(db.DB).First(&proposal, proposal.ID)
+		c.JSON(http.StatusCreated, proposal)
+	}
+}
+
+// ApproveProposal adds the calling admin's signature over
+// "Approve admin proposal <id>\nAction: <action>\nParams: <params>" and
+// executes the proposal once the threshold is met (admin only)
+func ApproveProposal(db *DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Signature string `json:"signature" binding:"required"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		proposal, ok := loadProposal(c, db)
+		if !ok {
+			return
+		}
+		if proposal.Status != ProposalPending {
+			c.JSON(http.StatusConflict, gin.H{"error": "proposal is " + proposal.Status})
+			return
+		}
+		if !requireActionPermission(c, db, proposalActions[proposal.Action]) {
+			return
+		}
+
+		admin := AdminAddress(c)
+		for _, a := range proposal.Approvals {
+			if utils.SameAddress(a.AdminAddress, admin) {
+				c.JSON(http.StatusConflict, gin.H{"error": "proposal already approved by this admin"})
+				return
+			}
+		}
+
+		valid, err := utils.VerifySignature(admin, req.Signature, approvalMessage(proposal))
+		if err != nil || !valid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+			return
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			// Re-check the status inside the transaction, since the proposal
+			// may have been executed or cancelled since it was loaded
+			if err := updatePendingProposal(tx, proposal.ID, map[string]interface{}{"updated_at": time.Now()}); err != nil {
+				return err
+			}
+			approval := ProposalApproval{ProposalID: proposal.ID, AdminAddress: admin, Signature: req.Signature}
+			if err := tx.Create(&approval).Error; err != nil {
+				return err
+			}
+			if err := RecordAudit(tx, c, "proposal.approve", "proposal", proposal.ID, nil, gin.H{"approver": admin}); err != nil {
+				return err
+			}
+			return executeIfApproved(tx, c, proposal)
+		})
+		if IsUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "proposal already approved by this admin"})
+			return
+		}
+		if errors.Is(err, errProposalClosed) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to approve proposal"})
+			return
+		}
+
+		proposal.Approvals = nil
+		withApprovals(db.DB).First(proposal, proposal.ID)
+		c.JSON(http.StatusOK, proposal)
+	}
+}
+
+// CancelProposal withdraws a pending proposal. Only the proposer or a
+// super-admin can cancel (admin only).
+func CancelProposal(db *DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		proposal, ok := loadProposal(c, db)
+		if !ok {
+			return
+		}
+		if proposal.Status != ProposalPending {
+			c.JSON(http.StatusConflict, gin.H{"error": "proposal is " + proposal.Status})
+			return
+		}
+
+		admin := AdminAddress(c)
+		role, err := GetAdminRole(db.DB, admin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !utils.SameAddress(proposal.ProposedBy, admin) && role != RoleSuperAdmin {
+			c.JSON(http.StatusForbidden, gin.H{"error": "only the proposer or a super-admin can cancel a proposal"})
+			return
+		}
+
+		err = db.Transaction(func(tx *gorm.DB) error {
+			if err := updatePendingProposal(tx, proposal.ID, map[string]interface{}{"status": ProposalCancelled}); err != nil {
+				return err
+			}
+			return RecordAudit(tx, c, "proposal.cancel", "proposal", proposal.ID,
+				gin.H{"status": ProposalPending}, gin.H{"status": ProposalCancelled})
+		})
+		if errors.Is(err, errProposalClosed) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel proposal"})
+			return
+		}
+
+		proposal.Status = ProposalCancelled
+		c.JSON(http.StatusOK, proposal)
+	}
+}
+
+// GetProposals returns a page of proposals, newest first, optionally
+// filtered by status and action (admin only)
+func GetProposals(db *DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := expireProposals(db.DB); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		query := db.Model(&AdminProposal{})
+		if status := c.Query("status"); status != "" {
+			query = query.Where("status = ?", status)
+		}
+		if action := c.Query("action"); action != "" {
+			query = query.Where("action = ?", action)
+		}
+
+		page, pageSize, offset := utils.ParsePagination(c)
+
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		var proposals []AdminProposal
+		if err := withApprovals(query).Order("id DESC").Offset(offset).Limit(pageSize).Find(&proposals).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"proposals":  proposals,
+			"pagination": utils.PaginationMeta(total, page, pageSize),
+		})
+	}
+}
+
+// GetProposal returns a proposal with its approvals (admin only)
+func GetProposal(db *DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		proposal, ok := loadProposal(c, db)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, proposal)
+	}
+}
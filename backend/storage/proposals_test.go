@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	moderatorA = "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23"
+	curatorB   = "0x19E7E376E7C213B7E7e7e46cc70A5dD086DAff2A"
+	moderatorC = "0x1563915e194D8CfBA1943570603F7606A3115508"
+)
+
+// adminContext is a request context signed by admin
+func adminContext(admin string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/admin/proposals/1/approve", nil)
+	c.Set(adminAddressKey, admin)
+	return c
+}
+
+func TestExecuteIfApprovedRunsProposalOnce(t *testing.T) {
+	db, _ := newLinkTestDB(t)
+
+	for address, role := range map[string]string{moderatorA: RoleModerator, curatorB: RoleCurator, moderatorC: RoleModerator} {
+		if err := db.Create(&AdminRole{Address: address, Role: role, GrantedBy: "config"}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	app := App{Name: "Example"}
+	if err := db.Create(&app).Error; err != nil {
+		t.Fatal(err)
+	}
+	params, _ := json.Marshal(delistParams{AppID: app.ID})
+	proposal := AdminProposal{
+		Action:     "app.delist",
+		Params:     params,
+		ProposedBy: moderatorA,
+		Status:     ProposalPending,
+		Threshold:  2,
+		ExpiresAt:  time.Now().Add(time.Hour),
+	}
+	if err := db.Create(&proposal).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	approve := func(admin string) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&ProposalApproval{ProposalID: proposal.ID, AdminAddress: admin}).Error; err != nil {
+				return err
+			}
+			stale := proposal
+			return executeIfApproved(tx, adminContext(admin), &stale)
+		})
+	}
+
+	// A curator cannot delist apps, so their approval does not count
+	for _, admin := range []string{moderatorA, curatorB} {
+		if err := approve(admin); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.First(&proposal, proposal.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if proposal.Status != ProposalPending {
+		t.Fatalf("status after one counted approval = %s, want pending", proposal.Status)
+	}
+
+	if err := approve(moderatorC); err != nil {
+		t.Fatal(err)
+	}
+
+	// An approval that loaded the proposal before it executed must not run
+	// it again
+	stale := proposal
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return executeIfApproved(tx, adminContext(moderatorA), &stale)
+	})
+	if !errors.Is(err, errProposalClosed) {
+		t.Fatalf("second execution: got %v, want errProposalClosed", err)
+	}
+
+	if err := db.First(&proposal, proposal.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if proposal.Status != ProposalExecuted || proposal.ExecutedAt == nil {
+		t.Errorf("proposal = %s executed at %v, want executed", proposal.Status, proposal.ExecutedAt)
+	}
+	var delists int64
+	if err := db.Model(&AuditEntry{}).Where("action = ?", "app.delist").Count(&delists).Error; err != nil {
+		t.Fatal(err)
+	}
+	if delists != 1 {
+		t.Errorf("app.delist ran %d times, want once", delists)
+	}
+	if err := db.First(&App{}, app.ID).Error; err == nil {
+		t.Error("app is still listed")
+	}
+}
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RatingPriorWeight is how many reviews at the site-wide mean each app's
+// Bayesian average starts from. Apps with few reviews stay close to the mean
+// until enough ratings accumulate.
+const RatingPriorWeight = 5
+
+// defaultRatingPrior is the prior mean used before any app has been rated
+const defaultRatingPrior = 3.0
+
+// RatingPrior returns the mean of all visible ratings across apps, used as
+// the prior of the Bayesian average
+func RatingPrior(db *gorm.DB) (float64, error) {
+	var totals struct {
+		Sum   int64
+		Count int64
+	}
+	err := db.Model(&AppRatingStat{}).
+		Select("COALESCE(SUM(rating_sum), 0) AS sum, COALESCE(SUM(rating_count), 0) AS count").
+		Scan(&totals).Error
+	if err != nil || totals.Count == 0 {
+		return defaultRatingPrior, err
+	}
+	return float64(totals.Sum) / float64(totals.Count), nil
+}
+
+// BayesianAverage returns the average rating pulled towards prior by
+// RatingPriorWeight virtual reviews
+func (s AppRatingStat) BayesianAverage(prior float64) float64 {
+	return (prior*RatingPriorWeight + float64(s.RatingSum)) / float64(RatingPriorWeight+s.RatingCount)
+}
+
+// orderByBayesianAverage orders apps joined with app_rating_stats by their
+// Bayesian average. Apps without ratings rank at the prior.
+func orderByBayesianAverage(prior float64, desc bool) clause.OrderBy {
+	sql := "(? + COALESCE(app_rating_stats.rating_sum, 0)) / (? + COALESCE(app_rating_stats.rating_count, 0))"
+	if desc {
+		sql += " DESC"
+	}
+	return clause.OrderBy{Expression: clause.Expr{
+		SQL:  sql,
+		Vars: []interface{}{prior * RatingPriorWeight, float64(RatingPriorWeight)},
+	}}
+}
@@ -0,0 +1,254 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/blockvantage/chain-app-store/backend/config"
+	"github.com/blockvantage/chain-app-store/backend/utils"
+)
+
+// Admin roles
+const (
+	RoleSuperAdmin = "super-admin"
+	RoleModerator  = "moderator"
+	RoleCurator    = "curator"
+	RoleFinance    = "finance"
+)
+
+// Admin permissions
+const (
+	PermHideContent       = "content.hide"       // Hide content and handle flags
+	PermFeatureApps       = "apps.feature"       // Feature apps
+	PermManageSettlements = "settlements.manage" // Seasons and reward distributions
+	PermManageAdmins      = "admins.manage"      // Grant and revoke admin roles
+	PermViewAudit         = "audit.view"         // Read the audit log
+)
+
+// RolePermissions lists the permissions granted by each role
+var RolePermissions = map[string][]string{
+	RoleSuperAdmin: {PermHideContent, PermFeatureApps, PermManageSettlements, PermManageAdmins, PermViewAudit},
+	RoleModerator:  {PermHideContent, PermViewAudit},
+	RoleCurator:    {PermFeatureApps, PermViewAudit},
+	RoleFinance:    {PermManageSettlements, PermViewAudit},
+}
+
+// errLastSuperAdmin is returned when a change would leave no super-admin
+var errLastSuperAdmin = errors.New("at least one super-admin must remain")
+
+// RoleHasPermission reports whether role grants permission
+func RoleHasPermission(role, permission string) bool {
+	for _, p := range RolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAdminRole returns the role of an admin wallet, empty if it has none
+func GetAdminRole(db *gorm.DB, address string) (string, error) {
+	if !common.IsHexAddress(address) {
+		return "", nil
+	}
+	var role AdminRole
+	err := db.Where("address = ?", utils.NormalizeAddress(address)).Limit(1).Find(&role).Error
+	return role.Role, err
+}
+
+// SeedAdminRoles makes the config adminWallets super-admins when no roles
+// exist yet. Later changes are made through the admin role endpoints.
+func SeedAdminRoles(db *gorm.DB, cfg *config.Config) error {
+	var count int64
+	if err := db.Model(&AdminRole{}).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	for _, wallet := range cfg.AdminWallets {
+		if !common.IsHexAddress(wallet) {
+			return fmt.Errorf("invalid admin wallet %q", wallet)
+		}
+		role := AdminRole{Address: utils.NormalizeAddress(wallet), Role: RoleSuperAdmin, GrantedBy: "config"}
+		if err := db.Where(AdminRole{Address: role.Address}).FirstOrCreate(&role).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// roleMessage builds the text an admin signs to set or revoke a role. An
+// empty role revokes. The timestamp and nonce of the signed admin request
+// tie the signature to that one request.
+func roleMessage(address, role string, timestamp int64, nonce string) string {
+	change := "Set admin role of " + address + " to " + role
+	if role == "" {
+		change = "Revoke admin role of " + address
+	}
+	return fmt.Sprintf("%s\nTimestamp: %d\nNonce: %s", change, timestamp, nonce)
+}
+
+// ensureSuperAdminRemains fails if no super-admin other than address exists
+func ensureSuperAdminRemains(tx *gorm.DB, address string) error {
+	var others int64
+	if err := tx.Model(&AdminRole{}).
+		Where("role = ? AND address <> ?", RoleSuperAdmin, address).
+		Count(&others).Error; err != nil {
+		return err
+	}
+	if others == 0 {
+		return errLastSuperAdmin
+	}
+	return nil
+}
+
+// GetAdminRoles lists admin wallets with their roles, and the permissions
+// of each role (admin only)
+func GetAdminRoles(db *DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var roles []AdminRole
+		if err := db.Order("role, address").Find(&roles).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		names := make([]string, 0, len(RolePermissions))
+		for name := range RolePermissions {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		c.JSON(http.StatusOK, gin.H{"admins": roles, "roles": names, "permissions": RolePermissions})
+	}
+}
+
+// grantsNewApproval reports whether changing a wallet's role from before to
+// after gives it a permission that approves proposals it did not hold
+func grantsNewApproval(before, after string) bool {
+	for _, action := range proposalActions {
+		if RoleHasPermission(after, action.permission) && !RoleHasPermission(before, action.permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// setAdminRole grants, changes or, with an empty role, revokes the role of
+// address and returns the stored role and the role it had before
+func setAdminRole(tx *gorm.DB, address, newRole, grantedBy string) (AdminRole, string, error) {
+	var role AdminRole
+	if err := tx.Where("address = ?", address).Limit(1).Find(&role).Error; err != nil {
+		return role, "", err
+	}
+	before := role.Role
+
+	// Demoting or revoking a super-admin must leave another one
+	if before == RoleSuperAdmin && newRole != RoleSuperAdmin {
+		if err := ensureSuperAdminRemains(tx, address); err != nil {
+			return role, before, err
+		}
+	}
+
+	switch {
+	case newRole == "" && role.ID == 0:
+		return role, before, gorm.ErrRecordNotFound
+	case newRole == "":
+		if err := tx.Unscoped().Delete(&role).Error; err != nil {
+			return role, before, err
+		}
+	default:
+		role.Address = address
+		role.Role = newRole
+		role.GrantedBy = grantedBy
+		if err := tx.Save(&role).Error; err != nil {
+			return role, before, err
+		}
+	}
+	return role, before, nil
+}
+
+// SetAdminRole grants, changes or, with an empty role, revokes the role of an
+// admin wallet. The body must be signed by the calling admin over
+// "Set admin role of <address> to <role>" or "Revoke admin role of <address>",
+// followed by the X-Admin-Timestamp and X-Admin-Nonce of the request. A role
+// that gives the wallet a new permission to approve proposals can only be
+// granted through an admin.role proposal (admin only).
+func SetAdminRole(db *DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Address   string `json:"address" binding:"required"`
+			Role      string `json:"role"`
+			Signature string `json:"signature" binding:"required"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		if !common.IsHexAddress(req.Address) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid address"})
+			return
+		}
+		if _, ok := RolePermissions[req.Role]; req.Role != "" && !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown role " + req.Role})
+			return
+		}
+
+		// The change itself must be signed by the authenticated admin
+		valid, err := utils.VerifySignature(AdminAddress(c), req.Signature, roleMessage(req.Address, req.Role, c.GetInt64(adminTimestampKey), c.GetString(adminNonceKey)))
+		if err != nil || !valid {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+			return
+		}
+
+		// Roles that can approve proposals are granted only by proposal, so
+		// one admin cannot add approvers of their own
+		address := utils.NormalizeAddress(req.Address)
+		current, err := GetAdminRole(db.DB, address)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if grantsNewApproval(current, req.Role) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "role " + req.Role + " can approve proposals and must be granted through an admin.role proposal"})
+			return
+		}
+
+		var role AdminRole
+		err = db.Transaction(func(tx *gorm.DB) error {
+			var before string
+			var err error
+			role, before, err = setAdminRole(tx, address, req.Role, AdminAddress(c))
+			if err != nil {
+				return err
+			}
+			return RecordAudit(tx, c, "admin.role", "admin", role.ID, gin.H{"address": address, "role": before}, gin.H{"address": address, "role": req.Role})
+		})
+		switch {
+		case errors.Is(err, errLastSuperAdmin):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "address has no admin role"})
+			return
+		case err != nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update admin role"})
+			return
+		}
+
+		if req.Role == "" {
+			c.JSON(http.StatusOK, gin.H{"success": true})
+			return
+		}
+		c.JSON(http.StatusOK, role)
+	}
+}
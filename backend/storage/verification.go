@@ -0,0 +1,464 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/blockvantage/chain-app-store/backend/config"
+	"github.com/blockvantage/chain-app-store/backend/storage/linkcheck"
+	"github.com/blockvantage/chain-app-store/backend/utils"
+)
+
+// Developer verification methods
+const (
+	VerifyByDeployer  = "deployer"
+	VerifyByOwner     = "owner"
+	VerifyByDNS       = "dns"
+	VerifyByWellKnown = "wellknown"
+)
+
+// Where developers publish their signed domain challenge
+const (
+	verificationDNSPrefix    = "_chainapphub."
+	verificationTXTPrefix    = "chainapphub-verification="
+	verificationWellKnown    = "/.well-known/chainapphub-verification.txt"
+	verificationFetchLimit   = 4 << 10
+	verificationFetchTimeout = 10 * time.Second
+)
+
+// errProofFailed is returned when the evidence does not prove control
+var errProofFailed = errors.New("proof failed")
+
+// proofError wraps errProofFailed with the reason
+func proofError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", errProofFailed, fmt.Sprintf(format, args...))
+}
+
+// contractProofMessage builds the text a developer signs to prove control
+// of a contract through the chain
+func contractProofMessage(appID uint, contract, method string) string {
+	return fmt.Sprintf("Verify developer of app %d\nContract: %s\nMethod: %s", appID, contract, method)
+}
+
+// domainChallengeMessage builds the text a developer signs and publishes on
+// the app's website domain
+func domainChallengeMessage(appID uint, domain string) string {
+	return fmt.Sprintf("Verify developer of app %d\nDomain: %s", appID, domain)
+}
+
+// websiteDomain returns the host of the app's website, or "" if it has none
+func websiteDomain(app App) string {
+	u, err := linkcheck.Parse(app.WebsiteURL)
+	if err != nil {
+		return ""
+	}
+	return linkcheck.Host(u)
+}
+
+// refreshAppVerified recomputes the badges of an app. The verified badge
+// needs an on-chain proof for every listed contract. The domain badge needs
+// a proof published on the website domain, which says nothing about who
+// controls the contracts.
+func refreshAppVerified(tx *gorm.DB, appID uint) error {
+	var total, verified, domainProofs int64
+	if err := tx.Model(&AppContract{}).Where("app_id = ?", appID).Count(&total).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&AppContract{}).Where("app_id = ? AND verified = ?", appID, true).Count(&verified).Error; err != nil {
+		return err
+	}
+	err := tx.Model(&DeveloperProof{}).
+		Where("app_id = ? AND method IN ?", appID, []string{VerifyByDNS, VerifyByWellKnown}).
+		Count(&domainProofs).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&App{}).Where("id = ?", appID).UpdateColumns(map[string]interface{}{
+		"verified":        total > 0 && verified == total,
+		"domain_verified": domainProofs > 0,
+	}).Error
+}
+
+// demoteDomainContractClaims clears contract claims that were verified by a
+// domain proof, which proves control of the website only, and recomputes
+// the badges of every app with such a claim or a domain proof
+func demoteDomainContractClaims(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		domainMethods := []string{VerifyByDNS, VerifyByWellKnown}
+		var appIDs []uint
+		if err := tx.Model(&AppContract{}).Where("verification_method IN ?", domainMethods).
+			Distinct().Pluck("app_id", &appIDs).Error; err != nil {
+			return err
+		}
+		var proofApps []uint
+		if err := tx.Model(&DeveloperProof{}).Where("method IN ?", domainMethods).
+			Distinct().Pluck("app_id", &proofApps).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&AppContract{}).Where("verification_method IN ?", domainMethods).Updates(map[string]interface{}{
+			"verified":            false,
+			"verification_method": "",
+			"verified_at":         nil,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("Cleared %d contract claims verified by domain proofs", result.RowsAffected)
+		}
+
+		for _, appID := range append(appIDs, proofApps...) {
+			if err := refreshAppVerified(tx, appID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// proveContract checks on-chain that the developer deployed or owns a
+// contract and returns the evidence
+func proveContract(ctx context.Context, cfg *config.Config, app App, contract, method, txHash string) (string, error) {
+	client, err := utils.DialChain(ctx, cfg.RpcUrl)
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	switch method {
+	case VerifyByDeployer:
+		info, err := client.GetTransaction(ctx, txHash)
+		if errors.Is(err, utils.ErrTxNotFound) {
+			return "", proofError("creation transaction not found")
+		}
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case !info.Success:
+			return "", proofError("creation transaction failed")
+		case !utils.SameAddress(info.ContractAddress, contract):
+			return "", proofError("transaction %s did not deploy %s", info.Hash, contract)
+		case !utils.SameAddress(info.From, app.DeveloperAddress):
+			return "", proofError("contract was deployed by %s, not the developer", info.From)
+		}
+		return info.Hash, nil
+	case VerifyByOwner:
+		owner, err := client.Owner(ctx, contract)
+		if err != nil {
+			return "", proofError("%v", err)
+		}
+		if !utils.SameAddress(owner, app.DeveloperAddress) {
+			return "", proofError("owner() returns %s, not the developer", owner)
+		}
+		return owner, nil
+	}
+	return "", proofError("unknown method %q", method)
+}
+
+// publishedSignatures fetches the signatures published on a domain by DNS
+// TXT record or .well-known file
+func publishedSignatures(ctx context.Context, domain, method string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, verificationFetchTimeout)
+	defer cancel()
+
+	var signatures []string
+	switch method {
+	case VerifyByDNS:
+		records, err := net.DefaultResolver.LookupTXT(ctx, verificationDNSPrefix+domain)
+		if err != nil {
+			return nil, proofError("no TXT record at %s%s", verificationDNSPrefix, domain)
+		}
+		for _, record := range records {
+			if strings.HasPrefix(record, verificationTXTPrefix) {
+				signatures = append(signatures, strings.TrimPrefix(record, verificationTXTPrefix))
+			}
+		}
+	case VerifyByWellKnown:
+		url := "https://" + domain + verificationWellKnown
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		// The website is chosen by the developer, so only public addresses
+		// are fetched
+		resp, err := linkcheck.NewClient(verificationFetchTimeout).Do(req)
+		if err != nil {
+			return nil, proofError("could not fetch %s", url)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, proofError("%s returned HTTP %d", url, resp.StatusCode)
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, verificationFetchLimit))
+		if err != nil {
+			return nil, proofError("could not read %s", url)
+		}
+		for _, line := range strings.Split(string(body), "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				signatures = append(signatures, line)
+			}
+		}
+	}
+	return signatures, nil
+}
+
+// proveDomain checks that the developer's signature of the domain challenge
+// is published on the app's website domain and returns it
+func proveDomain(ctx context.Context, app App, method string) (string, error) {
+	domain := websiteDomain(app)
+	if domain == "" {
+		return "", proofError("app has no website")
+	}
+	signatures, err := publishedSignatures(ctx, domain, method)
+	if err != nil {
+		return "", err
+	}
+	message := domainChallengeMessage(app.ID, domain)
+	for _, signature := range signatures {
+		if valid, err := utils.VerifySignature(app.DeveloperAddress, signature, message); err == nil && valid {
+			return signature, nil
+		}
+	}
+	return "", proofError("no valid developer signature published on %s", domain)
+}
+
+// verificationState is the verification status of an app and how to prove
+// control of its website
+func verificationState(db *gorm.DB, app App) (gin.H, error) {
+	var contracts []AppContract
+	if err := db.Where("app_id = ?", app.ID).Order("id").Find(&contracts).Error; err != nil {
+		return nil, err
+	}
+	var proofs []DeveloperProof
+	if err := db.Where("app_id = ?", app.ID).Order("id").Find(&proofs).Error; err != nil {
+		return nil, err
+	}
+
+	state := gin.H{
+		"appId":            app.ID,
+		"developerAddress": app.DeveloperAddress,
+		"verified":         app.Verified,
+		"domainVerified":   app.DomainVerified,
+		"contracts":        contracts,
+		"proofs":           proofs,
+	}
+	if domain := websiteDomain(app); domain != "" {
+		state["domainChallenge"] = gin.H{
+			"domain":       domain,
+			"message":      domainChallengeMessage(app.ID, domain),
+			"dnsRecord":    verificationDNSPrefix + domain,
+			"dnsValue":     verificationTXTPrefix + "<signature>",
+			"wellKnownUrl": "https://" + domain + verificationWellKnown,
+		}
+	}
+	return state, nil
+}
+
+// GetAppVerification returns the verification status of an app's developer
+func GetAppVerification(db *DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var app App
+		if err := db.Where("hidden = ?", false).First(&app, c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "app not found"})
+			return
+		}
+
+		state, err := verificationState(db.DB, app)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, state)
+	}
+}
+
+// VerifyDeveloper proves that an app's developer controls one of its
+// contracts, by deployment or owner(), or its website domain, by a signed
+// challenge published in DNS or a .well-known file. Contracts are verified
+// only by their own on-chain proof; a domain proof earns the domain badge
+// alone. Contracts verified for another app cannot be claimed.
+func VerifyDeveloper(db *DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Method    string `json:"method" binding:"required"`
+			Contract  string `json:"contract"`
+			TxHash    string `json:"txHash"`
+			Signature string `json:"signature"`
+		}
+
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		var app App
+		if err := db.Where("hidden = ?", false).First(&app, c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "app not found"})
+			return
+		}
+
+		// Apps of banned developers or with banned contracts or links
+		// cannot be verified
+		if !EnforceBans(c, db, AppBanTargets(app)) {
+			return
+		}
+
+		var contracts []AppContract
+		var evidence, subject string
+		var err error
+		switch req.Method {
+		case VerifyByDeployer, VerifyByOwner:
+			contract, parseErr := utils.ParseAddress(req.Contract)
+			if parseErr != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid contract: " + parseErr.Error()})
+				return
+			}
+			if req.Method == VerifyByDeployer && !utils.IsTxHash(req.TxHash) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "txHash of the creation transaction is required"})
+				return
+			}
+
+			// Only the developer may ask for a verification in their name
+			valid, sigErr := utils.VerifySignature(app.DeveloperAddress, req.Signature, contractProofMessage(app.ID, contract, req.Method))
+			if sigErr != nil || !valid {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+				return
+			}
+
+			if err := db.Where("app_id = ? AND address = ?", app.ID, contract).Find(&contracts).Error; err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if len(contracts) == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "contract is not listed by this app"})
+				return
+			}
+			subject = contract
+			evidence, err = proveContract(c.Request.Context(), cfg, app, contract, req.Method, req.TxHash)
+		case VerifyByDNS, VerifyByWellKnown:
+			subject = websiteDomain(app)
+			evidence, err = proveDomain(c.Request.Context(), app, req.Method)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("method must be %s, %s, %s or %s",
+				VerifyByDeployer, VerifyByOwner, VerifyByDNS, VerifyByWellKnown)})
+			return
+		}
+		if errors.Is(err, errProofFailed) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "failed to check proof: " + err.Error()})
+			return
+		}
+
+		addresses := make([]string, len(contracts))
+		for i, contract := range contracts {
+			addresses[i] = contract.Address
+		}
+		if !EnforceContractClaims(c, db, app.ID, addresses) {
+			return
+		}
+
+		now := time.Now()
+		err = db.Transaction(func(tx *gorm.DB) error {
+			for _, contract := range contracts {
+				if contract.Verified {
+					continue
+				}
+				err := tx.Model(&contract).Updates(map[string]interface{}{
+					"verified":            true,
+					"verification_method": req.Method,
+					"verified_at":         now,
+				}).Error
+				if err != nil {
+					return err
+				}
+			}
+			if err := tx.Create(&DeveloperProof{
+				AppID:     app.ID,
+				Method:    req.Method,
+				Subject:   subject,
+				Evidence:  evidence,
+				Signature: req.Signature,
+			}).Error; err != nil {
+				return err
+			}
+			return refreshAppVerified(tx, app.ID)
+		})
+		if IsUniqueViolation(err) {
+			c.JSON(http.StatusConflict, gin.H{"error": "contract was claimed by another app"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record verification"})
+			return
+		}
+
+		if err := db.First(&app, app.ID).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		state, err := verificationState(db.DB, app)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, state)
+	}
+}
+
+// RevokeVerification clears the verified claims, proofs and badges of an
+// app, for example after a proof turns out to be misleading (admin only)
+func RevokeVerification(db *DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var app App
+		if err := db.First(&app, c.Param("id")).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "app not found"})
+			return
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var verified []string
+			if err := tx.Model(&AppContract{}).Where("app_id = ? AND verified = ?", app.ID, true).
+				Pluck("address", &verified).Error; err != nil {
+				return err
+			}
+			err := tx.Model(&AppContract{}).Where("app_id = ?", app.ID).Updates(map[string]interface{}{
+				"verified":            false,
+				"verification_method": "",
+				"verified_at":         nil,
+			}).Error
+			if err != nil {
+				return err
+			}
+			if err := tx.Where("app_id = ?", app.ID).Delete(&DeveloperProof{}).Error; err != nil {
+				return err
+			}
+			if err := refreshAppVerified(tx, app.ID); err != nil {
+				return err
+			}
+			return RecordAudit(tx, c, "app.unverify", FlagTypeApp, app.ID,
+				gin.H{"verified": app.Verified, "domainVerified": app.DomainVerified, "contracts": verified},
+				gin.H{"verified": false, "domainVerified": false})
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke verification"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	}
+}
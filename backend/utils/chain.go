@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+)
+
+// ErrTxNotFound is returned when a transaction is unknown or still pending
+var ErrTxNotFound = errors.New("transaction not found or not yet mined")
+
+var txHashPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{64}$`)
+
+// IsTxHash reports whether s is a well-formed transaction hash
+func IsTxHash(s string) bool {
+	return txHashPattern.MatchString(s)
+}
+
+// ChainClient wraps an RPC connection to the configured chain
+type ChainClient struct {
+	client *ethclient.Client
+}
+
+// TxInfo is the subset of a mined transaction the backend checks against
+type TxInfo struct {
+	Hash            string
+	From            string
+	To              string // empty for contract creations
+	ContractAddress string // contract deployed by a creation transaction
+	Success         bool
+	BlockNumber     uint64
+	LogAddresses    []string // addresses of contracts that emitted logs
+}
+
+// InteractsWith reports whether the transaction called one of the contracts
+// directly or caused one of them to emit a log
+func (t *TxInfo) InteractsWith(contracts []string) bool {
+	for _, contract := range contracts {
+		if SameAddress(t.To, contract) {
+			return true
+		}
+		for _, emitter := range t.LogAddresses {
+			if SameAddress(emitter, contract) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DialChain connects to the RPC endpoint
+func DialChain(ctx context.Context, rpcURL string) (*ChainClient, error) {
+	if rpcURL == "" {
+		return nil, errors.New("rpc url is not configured")
+	}
+	client, err := ethclient.DialContext(ctx, rpcURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to rpc: %w", err)
+	}
+	return &ChainClient{client: client}, nil
+}
+
+// Close closes the RPC connection
+func (cc *ChainClient) Close() {
+	cc.client.Close()
+}
+
+// GetTransaction fetches a mined transaction together with its receipt
+func (cc *ChainClient) GetTransaction(ctx context.Context, hash string) (*TxInfo, error) {
+	if !IsTxHash(hash) {
+		return nil, fmt.Errorf("invalid transaction hash %q", hash)
+	}
+	txHash := common.HexToHash(hash)
+
+	tx, pending, err := cc.client.TransactionByHash(ctx, txHash)
+	if err != nil {
+		if errors.Is(err, ethereum.NotFound) {
+			return nil, ErrTxNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch transaction: %w", err)
+	}
+	if pending {
+		return nil, ErrTxNotFound
+	}
+
+	receipt, err := cc.client.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		if errors.Is(err, ethereum.NotFound) {
+			return nil, ErrTxNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch receipt: %w", err)
+	}
+
+	from, err := cc.client.TransactionSender(ctx, tx, receipt.BlockHash, receipt.TransactionIndex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover sender: %w", err)
+	}
+
+	info := &TxInfo{
+		Hash:        txHash.Hex(),
+		From:        from.Hex(),
+		Success:     receipt.Status == types.ReceiptStatusSuccessful,
+		BlockNumber: receipt.BlockNumber.Uint64(),
+	}
+	if tx.To() != nil {
+		info.To = tx.To().Hex()
+	} else {
+		info.ContractAddress = receipt.ContractAddress.Hex()
+	}
+	for _, l := range receipt.Logs {
+		info.LogAddresses = append(info.LogAddresses, l.Address.Hex())
+	}
+
+	return info, nil
+}
+
+// NonceAt returns the number of transactions sent from an address
+func (cc *ChainClient) NonceAt(ctx context.Context, address string) (uint64, error) {
+	nonce, err := cc.client.NonceAt(ctx, common.HexToAddress(address), nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch nonce: %w", err)
+	}
+	return nonce, nil
+}
+
+// BalanceAt returns the native token balance of an address in wei
+func (cc *ChainClient) BalanceAt(ctx context.Context, address string) (*big.Int, error) {
+	balance, err := cc.client.BalanceAt(ctx, common.HexToAddress(address), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch balance: %w", err)
+	}
+	return balance, nil
+}
+
+// CodeAt returns the bytecode deployed at an address, which is empty for
+// wallets and undeployed addresses
+func (cc *ChainClient) CodeAt(ctx context.Context, address string) ([]byte, error) {
+	code, err := cc.client.CodeAt(ctx, common.HexToAddress(address), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch code: %w", err)
+	}
+	return code, nil
+}
+
+// ownerSelector is the ABI selector of owner()
+var ownerSelector = common.FromHex("0x8da5cb5b")
+
+// Owner calls owner() on a contract and returns the address it reports
+func (cc *ChainClient) Owner(ctx context.Context, contract string) (string, error) {
+	to := common.HexToAddress(contract)
+	out, err := cc.client.CallContract(ctx, ethereum.CallMsg{To: &to, Data: ownerSelector}, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to call owner(): %w", err)
+	}
+	if len(out) != 32 {
+		return "", errors.New("contract does not implement owner()")
+	}
+	return common.BytesToAddress(out[12:]).Hex(), nil
+}
+
+// SameAddress compares two hex addresses ignoring checksum casing
+func SameAddress(a, b string) bool {
+	if !common.IsHexAddress(a) || !common.IsHexAddress(b) {
+		return false
+	}
+	return common.HexToAddress(a) == common.HexToAddress(b)
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrTxRejected is returned when a transaction does not prove an interaction
+var ErrTxRejected = errors.New("transaction rejected")
+
+// VerifyInteraction confirms through RPC that txHash succeeded, was sent by
+// sender and interacted with one of the given contracts, either as the
+// direct target or through logs the contracts emitted
+func VerifyInteraction(ctx context.Context, rpcURL, txHash, sender string, contracts []string) error {
+	if !IsTxHash(txHash) {
+		return fmt.Errorf("%w: malformed transaction hash", ErrTxRejected)
+	}
+	if len(contracts) == 0 {
+		return fmt.Errorf("%w: app has no contract addresses to verify against", ErrTxRejected)
+	}
+
+	client, err := DialChain(ctx, rpcURL)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	info, err := client.GetTransaction(ctx, txHash)
+	if errors.Is(err, ErrTxNotFound) {
+		return fmt.Errorf("%w: %v", ErrTxRejected, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !info.Success:
+		return fmt.Errorf("%w: transaction failed on-chain", ErrTxRejected)
+	case !SameAddress(info.From, sender):
+		return fmt.Errorf("%w: transaction was not sent by %s", ErrTxRejected, sender)
+	case !info.InteractsWith(contracts):
+		return fmt.Errorf("%w: transaction did not interact with the app's contracts", ErrTxRejected)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// MerkleTree is a keccak256 Merkle tree with sorted leaves and sorted-pair
+// hashing, matching the trees consumed by Uniswap-style MerkleDistributor
+// contracts and OpenZeppelin's MerkleProof library. A node without a sibling
+// is carried up to the next layer unchanged.
+type MerkleTree struct {
+	layers [][]common.Hash
+	index  map[common.Hash]int
+}
+
+// DistributorLeaf hashes a claim the way MerkleDistributor does:
+// keccak256(abi.encodePacked(uint256 index, address account, uint256 amount))
+func DistributorLeaf(index uint64, account common.Address, amount *big.Int) common.Hash {
+	return crypto.Keccak256Hash(
+		math.U256Bytes(new(big.Int).SetUint64(index)),
+		account.Bytes(),
+		math.U256Bytes(new(big.Int).Set(amount)),
+	)
+}
+
+// NewMerkleTree builds a tree over the given leaves
+func NewMerkleTree(leaves []common.Hash) *MerkleTree {
+	sorted := make([]common.Hash, len(leaves))
+	copy(sorted, leaves)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i][:], sorted[j][:]) < 0
+	})
+
+	// Drop duplicate leaves
+	unique := sorted[:0]
+	for i, leaf := range sorted {
+		if i == 0 || leaf != sorted[i-1] {
+			unique = append(unique, leaf)
+		}
+	}
+
+	tree := &MerkleTree{index: make(map[common.Hash]int, len(unique))}
+	for i, leaf := range unique {
+		tree.index[leaf] = i
+	}
+
+	layer := unique
+	tree.layers = append(tree.layers, layer)
+	for len(layer) > 1 {
+		next := make([]common.Hash, 0, (len(layer)+1)/2)
+		for i := 0; i < len(layer); i += 2 {
+			if i+1 < len(layer) {
+				next = append(next, hashPair(layer[i], layer[i+1]))
+			} else {
+				next = append(next, layer[i])
+			}
+		}
+		tree.layers = append(tree.layers, next)
+		layer = next
+	}
+
+	return tree
+}
+
+// Root returns the Merkle root, or the zero hash for an empty tree
+func (t *MerkleTree) Root() common.Hash {
+	top := t.layers[len(t.layers)-1]
+	if len(top) == 0 {
+		return common.Hash{}
+	}
+	return top[0]
+}
+
+// Proof returns the sibling hashes proving leaf is part of the tree
+func (t *MerkleTree) Proof(leaf common.Hash) ([]common.Hash, error) {
+	idx, ok := t.index[leaf]
+	if !ok {
+		return nil, errors.New("leaf is not in the tree")
+	}
+
+	var proof []common.Hash
+	for _, layer := range t.layers[:len(t.layers)-1] {
+		pair := idx ^ 1
+		if pair < len(layer) {
+			proof = append(proof, layer[pair])
+		}
+		idx /= 2
+	}
+	return proof, nil
+}
+
+// VerifyMerkleProof checks a proof produced by MerkleTree.Proof
+func VerifyMerkleProof(proof []common.Hash, root, leaf common.Hash) bool {
+	computed := leaf
+	for _, sibling := range proof {
+		computed = hashPair(computed, sibling)
+	}
+	return computed == root
+}
+
+func hashPair(a, b common.Hash) common.Hash {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return crypto.Keccak256Hash(a[:], b[:])
+}
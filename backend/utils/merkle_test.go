@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func testLeaves(n int) []common.Hash {
+	leaves := make([]common.Hash, n)
+	for i := range leaves {
+		account := common.BigToAddress(big.NewInt(int64(1000 + i)))
+		leaves[i] = DistributorLeaf(uint64(i), account, big.NewInt(int64(i+1)*1e18))
+	}
+	return leaves
+}
+
+func TestDistributorLeafMatchesPackedEncoding(t *testing.T) {
+	account := common.HexToAddress("0x2c7536E3605D9C16a7a3D7b1898e529396a65c23")
+	amount, _ := new(big.Int).SetString("1500000000000000000", 10)
+
+	packed := append(common.LeftPadBytes(big.NewInt(7).Bytes(), 32), account.Bytes()...)
+	packed = append(packed, common.LeftPadBytes(amount.Bytes(), 32)...)
+	if len(packed) != 84 {
+		t.Fatalf("packed claim is %d bytes, want 84", len(packed))
+	}
+
+	if got, want := DistributorLeaf(7, account, amount), crypto.Keccak256Hash(packed); got != want {
+		t.Errorf("DistributorLeaf = %s, want %s", got.Hex(), want.Hex())
+	}
+}
+
+func TestMerkleProofsVerify(t *testing.T) {
+	// Odd sizes carry a node up without a sibling
+	for n := 1; n <= 9; n++ {
+		leaves := testLeaves(n)
+		tree := NewMerkleTree(leaves)
+		root := tree.Root()
+
+		for i, leaf := range leaves {
+			proof, err := tree.Proof(leaf)
+			if err != nil {
+				t.Fatalf("%d leaves: proof of leaf %d: %v", n, i, err)
+			}
+			if !VerifyMerkleProof(proof, root, leaf) {
+				t.Errorf("%d leaves: proof of leaf %d does not verify", n, i)
+			}
+			if VerifyMerkleProof(proof, root, testLeaves(n + 1)[n]) {
+				t.Errorf("%d leaves: proof of leaf %d verifies another leaf", n, i)
+			}
+			if len(proof) > 0 {
+				tampered := append([]common.Hash{}, proof...)
+				tampered[0][0] ^= 1
+				if VerifyMerkleProof(tampered, root, leaf) {
+					t.Errorf("%d leaves: tampered proof of leaf %d verifies", n, i)
+				}
+			}
+		}
+	}
+}
+
+func TestMerkleTreeShape(t *testing.T) {
+	leaves := testLeaves(2)
+
+	// Pairs are hashed in sorted order whatever the input order
+	a, b := leaves[0], leaves[1]
+	if a.Big().Cmp(b.Big()) > 0 {
+		a, b = b, a
+	}
+	want := crypto.Keccak256Hash(a[:], b[:])
+	for _, order := range [][]common.Hash{{leaves[0], leaves[1]}, {leaves[1], leaves[0]}} {
+		if root := NewMerkleTree(order).Root(); root != want {
+			t.Errorf("root of two leaves = %s, want %s", root.Hex(), want.Hex())
+		}
+	}
+
+	// A single leaf is its own root with an empty proof
+	single := NewMerkleTree(leaves[:1])
+	if single.Root() != leaves[0] {
+		t.Errorf("root of one leaf = %s, want the leaf", single.Root().Hex())
+	}
+	if proof, err := single.Proof(leaves[0]); err != nil || len(proof) != 0 {
+		t.Errorf("proof of the only leaf = %v, %v; want empty", proof, err)
+	}
+
+	// Duplicate leaves are dropped
+	if NewMerkleTree([]common.Hash{a, b, a}).Root() != want {
+		t.Error("duplicate leaf changed the root")
+	}
+
+	if root := NewMerkleTree(nil).Root(); root != (common.Hash{}) {
+		t.Errorf("root of an empty tree = %s, want zero", root.Hex())
+	}
+	if _, err := NewMerkleTree(leaves).Proof(testLeaves(3)[2]); err == nil {
+		t.Error("Proof of a leaf outside the tree succeeded")
+	}
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxPageSize caps the page size a client can request
+const MaxPageSize = 100
+
+// ParsePagination reads the page and pageSize query parameters and returns
+// the page, page size and row offset to use
+func ParsePagination(c *gin.Context) (int, int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page < 1 {
+		page = 1
+	}
+
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	return page, pageSize, (page - 1) * pageSize
+}
+
+// PaginationMeta builds the pagination block returned alongside list responses
+func PaginationMeta(total int64, page, pageSize int) gin.H {
+	return gin.H{
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+		"pages":    (total + int64(pageSize) - 1) / int64(pageSize),
+	}
+}
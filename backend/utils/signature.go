@@ -3,9 +3,11 @@ package utils
 import (
 	"crypto/ecdsa"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 )
 
@@ -25,9 +27,10 @@ func RecoverAddressFromSignature(signature, message string) (string, error) {
 		return "", fmt.Errorf("invalid signature length: got %d, want 65", len(sig))
 	}
 
-	// Adjust V value (last byte)
-	if sig[64] < 27 {
-		sig[64] += 27
+	// Adjust V value (last byte): wallets return 27/28, while SigToPub
+	// expects the raw 0/1 recovery id
+	if sig[64] >= 27 {
+		sig[64] -= 27
 	}
 
 	// Prepare the message hash
@@ -58,7 +61,9 @@ func VerifySignature(address, signature, message string) (bool, error) {
 	return normalizedAddress == normalizedRecovered, nil
 }
 
-// SignMessage signs a message with a private key (for testing purposes)
+// SignMessage signs a message with a private key the way personal_sign
+// does, so VerifySignature and wallets can check it. The server signs boost
+// receipts with it.
 func SignMessage(privateKeyHex, message string) (string, error) {
 	// Remove "0x" prefix if present
 	privateKeyHex = strings.TrimPrefix(privateKeyHex, "0x")
@@ -84,6 +89,9 @@ func SignMessage(privateKeyHex, message string) (string, error) {
 		return "", fmt.Errorf("failed to sign message: %w", err)
 	}
 
+	// Use the 27/28 recovery id expected by personal_sign verifiers
+	sig[64] += 27
+
 	// Return the signature as a hex string
 	return "0x" + hex.EncodeToString(sig), nil
 }
@@ -112,3 +120,23 @@ func GetAddressFromPrivateKey(privateKeyHex string) (string, error) {
 	address := crypto.PubkeyToAddress(*publicKey)
 	return address.Hex(), nil
 }
+
+// NormalizeAddress returns the EIP-55 checksummed form of a hex address
+func NormalizeAddress(address string) string {
+	return common.HexToAddress(address).Hex()
+}
+
+// ParseAddress validates a hex address and returns its checksummed form.
+// All-lowercase and all-uppercase addresses carry no checksum and are
+// accepted; mixed-case addresses must match their EIP-55 checksum.
+func ParseAddress(address string) (string, error) {
+	if !strings.HasPrefix(address, "0x") || len(address) != 42 || !common.IsHexAddress(address) {
+		return "", errors.New("not a 0x-prefixed 20-byte hex address")
+	}
+	checksummed := NormalizeAddress(address)
+	digits := address[2:]
+	if digits != strings.ToLower(digits) && digits != strings.ToUpper(digits) && address != checksummed {
+		return "", errors.New("invalid EIP-55 checksum")
+	}
+	return checksummed, nil
+}